@@ -1,12 +1,15 @@
 package main
 
 import (
+	"encoding/json"
+	"strconv"
 	"syscall/js"
 	"time"
 
 	"github.com/nathannam/incident-commander-game/internal/game"
 	"github.com/nathannam/incident-commander-game/internal/renderer"
 	"github.com/nathannam/incident-commander-game/internal/input"
+	"github.com/nathannam/incident-commander-game/internal/replay"
 )
 
 func main() {
@@ -35,18 +38,90 @@ func main() {
 
 	println("✅ Canvas found, initializing game...")
 
-	// Initialize game components
-	g := game.New(20, 20)
+	// Online matches are simulated by the server
+	if onlineFromURL() {
+		playOnline(canvas)
+		return
+	}
+
+	// Initialize game components, offering to resume an autosaved game and
+	// recording the session so it can be replayed
+	first := game.DefaultLevelPack().Levels[0]
+	cfg := replay.Config{Width: first.Width, Height: first.Height, Seed: seedFromURL(), Endless: endlessFromURL(), Mode: modeFromURL(), Edges: edgesFromURL(), Players: playersFromURL(), Win: winFromURL()}
+	if saved := loadSave(); saved != "" {
+		if js.Global().Call("confirm", "Resume your saved Incident Commander game?").Bool() {
+			cfg.State = json.RawMessage(saved)
+		}
+		clearSave()
+	}
+	rec, err := replay.NewRecorder(cfg, game.RealClock{})
+	if err != nil {
+		println("⚠️ Could not resume saved game:", err.Error())
+		cfg.State = nil
+		rec, _ = replay.NewRecorder(cfg, game.RealClock{})
+	}
+	g := rec.Game()
+	println("🎲 Seed:", g.GetSeed())
+	exposeReplayDownload(rec)
 	r := renderer.New(canvas)
 	inputHandler := input.New()
+	inputHandler.SetPlayers(len(g.GetPlayers()))
 
 	println("✅ Game components initialized")
 
 	// Set up event listeners
-	inputHandler.SetupEventListeners(g)
+	inputHandler.SetupEventListeners()
 
 	println("✅ Event listeners set up")
 
+	// Log notable gameplay events to the browser console
+	g.Subscribe(func(e game.Event) {
+		switch e.Kind {
+		case game.EventLevelCompleted:
+			println("🎉 Level", e.Level, "complete, bonus:", e.Points)
+		case game.EventGameOver:
+			println("💀 Game over on level", e.Level, "with score", e.Score, "(seed", g.GetSeed(), ")")
+			if players := g.GetPlayers(); len(players) > 1 {
+				for _, p := range players {
+					println("   "+p.Name+":", p.Score)
+				}
+				if e.Player >= 0 {
+					println("🏁", players[e.Player].Name, "wins the match")
+				} else {
+					println("🏁 The match is a draw")
+				}
+			}
+			println("💾 Run downloadReplay() in the console to save this session")
+			clearSave()
+		case game.EventVictory:
+			summary := g.GetSummary()
+			println("🏆 All levels cleared with score", summary.Score, "in", summary.TotalTime.Round(time.Second).String())
+			for _, level := range summary.Levels {
+				println("   Level", level.Level, level.Name+":", level.Score, "points in", level.Elapsed.Round(time.Second).String())
+			}
+			clearSave()
+		case game.EventBoardSaturated:
+			println("🧱 Board saturated on level", e.Level, "with score", e.Score, "(seed", g.GetSeed(), ")")
+			clearSave()
+		case game.EventPaused:
+			saveGame(g)
+		}
+	})
+
+	// Pause and autosave when the tab is hidden
+	js.Global().Get("document").Call("addEventListener", "visibilitychange", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !js.Global().Get("document").Get("hidden").Bool() {
+			return nil
+		}
+		switch g.GetState() {
+		case game.Playing:
+			rec.Apply([]game.Command{game.PauseCommand()}) // Saves via EventPaused
+		case game.Paused, game.LevelComplete:
+			saveGame(g)
+		}
+		return nil
+	}))
+
 	// Initial render
 	r.Render(g)
 
@@ -56,25 +131,27 @@ func main() {
 	var gameLoop js.Func
 	var lastUpdate float64
 	
-	// Better speed progression - faster but still playable
-	getTargetFPS := func(level int) float64 {
-		// Level 1: 2 FPS (500ms), Level 10: 8 FPS (125ms)
-		fps := 1.5 + float64(level)*0.65 // 2.15 to 8 FPS range
-		if fps > 8 {
-			fps = 8 // Maximum 8 FPS
-		}
-		return fps
-	}
-	
 	gameLoop = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		now := args[0].Float()
-		targetFPS := getTargetFPS(g.GetLevel())
+		// The game owns its pace: the level's tick interval with any active
+		// speed modifiers applied
+		tickInterval := float64(g.GetTickInterval().Milliseconds())
 		
-		if now-lastUpdate >= 1000.0/targetFPS {
+		// Apply input as soon as it arrives so pause and restart feel instant
+		inputHandler.PollGamepads()
+		if cmds := inputHandler.Commands(); len(cmds) > 0 {
+			rec.Apply(cmds)
+			r.Render(g)
+		}
+		
+		if now-lastUpdate >= tickInterval {
 			// Always update to handle level transitions, but render depends on game state
-			g.Update()
+			rec.Step(nil)
 			r.Render(g)
 			lastUpdate = now
+		} else if r.Animating() {
+			// Keep drawing between ticks while the board rescales or alerts pulse
+			r.Render(g)
 		}
 		
 		// Continue the animation loop
@@ -91,4 +168,143 @@ func main() {
 	// Keep the program running - use a channel instead of select {}
 	done := make(chan bool)
 	<-done
-}
\ No newline at end of file
+}
+
+// urlParams returns the query parameters of the page URL
+func urlParams() js.Value {
+	return js.Global().Get("URLSearchParams").New(js.Global().Get("location").Get("search"))
+}
+
+// urlParam reads a parameter from the page URL, or "" when it is missing
+func urlParam(name string) string {
+	if value := urlParams().Call("get", name); !value.IsNull() {
+		return value.String()
+	}
+	return ""
+}
+
+// urlHas reports whether the page URL sets a parameter, with or without a
+// value, e.g. ?endless
+func urlHas(name string) bool {
+	return urlParams().Call("has", name).Bool()
+}
+
+// seedFromURL reads the game seed from the page URL, e.g. ?seed=1234,
+// falling back to a time-based seed
+func seedFromURL() int64 {
+	if seed := urlParam("seed"); seed != "" {
+		if n, err := strconv.ParseInt(seed, 10, 64); err == nil {
+			return n
+		}
+		println("⚠️ Ignoring invalid seed:", seed)
+	}
+	return time.Now().UnixNano()
+}
+
+// endlessFromURL reports whether the page asked for endless mode, e.g.
+// ?endless=1
+func endlessFromURL() bool {
+	return urlHas("endless")
+}
+
+// modeFromURL reads the game mode from the page URL, e.g. ?mode=classic,
+// falling back to LightCycle
+func modeFromURL() game.GameMode {
+	if name := urlParam("mode"); name != "" {
+		mode, err := game.ParseGameMode(name)
+		if err == nil {
+			return mode
+		}
+		println("⚠️ Ignoring", err.Error())
+	}
+	return game.LightCycle
+}
+
+// edgesFromURL reads the board edge policy from the page URL, e.g.
+// ?edges=wrap, falling back to each level's own edges
+func edgesFromURL() string {
+	name := urlParam("edges")
+	if name == "" {
+		return ""
+	}
+	if _, err := game.ParseEdgePolicy(name); err != nil {
+		println("⚠️ Ignoring", err.Error())
+		return ""
+	}
+	return name
+}
+
+// playersFromURL reads the number of local players from the page URL, e.g.
+// ?players=2, falling back to a single commander
+func playersFromURL() int {
+	if players := urlParam("players"); players != "" {
+		if n, err := strconv.Atoi(players); err == nil && n >= 1 && n <= game.MaxPlayers {
+			return n
+		}
+		println("⚠️ Ignoring invalid player count:", players)
+	}
+	return 1
+}
+
+// winFromURL reads how a multiplayer match is decided from the page URL,
+// e.g. ?win=score, falling back to last one standing
+func winFromURL() string {
+	name := urlParam("win")
+	if name == "" {
+		return ""
+	}
+	if _, err := game.ParseWinCondition(name); err != nil {
+		println("⚠️ Ignoring", err.Error())
+		return ""
+	}
+	return name
+}
+
+// saveKey is the localStorage key holding the autosaved game
+const saveKey = "incident-commander-save"
+
+// saveGame stores the game in localStorage
+func saveGame(g *game.Game) {
+	data, err := game.Marshal(g)
+	if err != nil {
+		println("❌ Failed to save game:", err.Error())
+		return
+	}
+	js.Global().Get("localStorage").Call("setItem", saveKey, string(data))
+	println("💾 Game saved")
+}
+
+// loadSave returns the autosaved game, or "" if there is none
+func loadSave() string {
+	saved := js.Global().Get("localStorage").Call("getItem", saveKey)
+	if saved.IsNull() {
+		return ""
+	}
+	return saved.String()
+}
+
+// clearSave removes the autosaved game
+func clearSave() {
+	js.Global().Get("localStorage").Call("removeItem", saveKey)
+}
+
+// exposeReplayDownload registers a downloadReplay() console function that
+// saves the recorded session as a JSON replay file
+func exposeReplayDownload(rec *replay.Recorder) {
+	js.Global().Set("downloadReplay", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		data, err := json.Marshal(rec.Replay())
+		if err != nil {
+			println("❌ Failed to encode replay:", err.Error())
+			return nil
+		}
+		
+		blob := js.Global().Get("Blob").New([]interface{}{string(data)}, map[string]interface{}{"type": "application/json"})
+		url := js.Global().Get("URL").Call("createObjectURL", blob)
+		link := js.Global().Get("document").Call("createElement", "a")
+		link.Set("href", url)
+		link.Set("download", "incident-commander-"+strconv.FormatInt(rec.Replay().Config.Seed, 10)+".replay.json")
+		link.Call("click")
+		js.Global().Get("URL").Call("revokeObjectURL", url)
+		return nil
+	}))
+}
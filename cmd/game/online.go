@@ -0,0 +1,358 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"syscall/js"
+	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+	"github.com/nathannam/incident-commander-game/internal/input"
+	"github.com/nathannam/incident-commander-game/internal/netplay"
+	"github.com/nathannam/incident-commander-game/internal/renderer"
+)
+
+// onlineFromURL reports whether the page asked to play in an online room
+// instead of locally, e.g. ?online=1
+func onlineFromURL() bool {
+	return urlHas("online")
+}
+
+// roomsURL returns the WebSocket address of the rooms on the server that
+// served the page
+func roomsURL() string {
+	location := js.Global().Get("location")
+	scheme := "ws:"
+	if location.Get("protocol").String() == "https:" {
+		scheme = "wss:"
+	}
+	return scheme + "//" + location.Get("host").String() + "/ws"
+}
+
+// onlineSession is the client side of an online room: the lobby screen
+// while players gather, then the room's game. The server runs the game; the
+// session sends its turns and predicts its own commander between the
+// server's states so turns show at once.
+type onlineSession struct {
+	document js.Value
+	renderer *renderer.Renderer
+	input    *input.InputHandler
+	ws       js.Value // Connection to the room, undefined before the first one
+
+	room  string          // Code of the room joined, "" when not in one
+	seat  int             // Seat in the room, which is the player ID in a game, or -1 when spectating
+	ready bool            // Whether this player is ready, as last told by the server
+	pack  *game.LevelPack // Level pack of the running game
+	g     *game.Game      // Game shown on the board, nil in the lobby
+
+	predictor *netplay.Predictor // Prediction of the running game, nil in the lobby
+	lastStep  time.Time          // When the prediction last stepped
+}
+
+// playOnline shows the online lobby and plays the room's games. A room code
+// in the URL, e.g. ?online=1&room=K7QX, joins that room straight away. It
+// never returns.
+func playOnline(canvas js.Value) {
+	s := &onlineSession{
+		document: js.Global().Get("document"),
+		renderer: renderer.New(canvas),
+		input:    input.New(),
+		seat:     -1,
+	}
+	s.input.SetupEventListeners()
+	s.setupLobby()
+	s.showLobby(true)
+	setStatus("🌐 Online")
+	if room := urlParam("room"); room != "" {
+		s.connect(room)
+	}
+
+	// Send turns as soon as they are pressed, step the prediction at the
+	// game's pace and keep animating between ticks; pause and restart belong
+	// to the server online
+	var loop js.Func
+	loop = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		s.input.PollGamepads()
+		for _, cmd := range s.input.Commands() {
+			if cmd.Kind == game.CommandDirection && s.predictor != nil && s.seat >= 0 {
+				s.send(s.predictor.Turn(cmd.Direction))
+				s.renderer.Render(s.g)
+			}
+		}
+		if s.g != nil && time.Since(s.lastStep) >= s.g.GetTickInterval() {
+			s.lastStep = time.Now()
+			s.predictor.Step()
+			s.g = s.predictor.Game()
+			s.renderer.Render(s.g)
+		} else if s.g != nil && s.renderer.Animating() {
+			s.renderer.Render(s.g)
+		}
+		js.Global().Call("requestAnimationFrame", loop)
+		return nil
+	})
+	js.Global().Call("requestAnimationFrame", loop)
+
+	println("✅ Online mode started")
+	done := make(chan bool)
+	<-done
+}
+
+// setupLobby fills in the lobby form from the URL and wires up its controls
+func (s *onlineSession) setupLobby() {
+	s.element("lobby-name").Set("value", urlParam("name"))
+	s.element("lobby-code").Set("value", urlParam("room"))
+
+	s.onEvent("lobby-create", "click", func() { s.connect("") })
+	s.onEvent("lobby-join", "click", func() {
+		if code := strings.TrimSpace(s.element("lobby-code").Get("value").String()); code != "" {
+			s.connect(code)
+		}
+	})
+	s.onEvent("lobby-ready", "click", func() {
+		s.send(netplay.Message{Type: netplay.MsgReady, Ready: !s.ready})
+	})
+	s.onEvent("lobby-leave", "click", func() {
+		if s.connected() {
+			s.ws.Call("close")
+		}
+	})
+	for _, id := range []string{"lobby-pack", "lobby-mode", "lobby-max"} {
+		s.onEvent(id, "change", s.sendSettings)
+	}
+}
+
+// connect opens a connection and joins the room with the given code, or
+// opens a new room when code is empty
+func (s *onlineSession) connect(code string) {
+	if s.connected() {
+		s.ws.Call("close")
+	}
+	s.lobbyStatus("🌐 Connecting...")
+	ws := js.Global().Get("WebSocket").New(roomsURL())
+	s.ws = ws
+	ws.Set("onopen", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		name := s.element("lobby-name").Get("value").String()
+		s.send(netplay.Message{Type: netplay.MsgHello, Room: strings.ToUpper(code), Name: name})
+		return nil
+	}))
+	ws.Set("onmessage", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		msg, err := netplay.Decode([]byte(args[0].Get("data").String()))
+		if err != nil {
+			println("⚠️", err.Error())
+			return nil
+		}
+		s.receive(msg)
+		return nil
+	}))
+	ws.Set("onclose", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !s.ws.Equal(ws) {
+			return nil // Replaced by a newer connection
+		}
+		if s.room != "" {
+			println("🔌 Left room", s.room)
+		}
+		s.room, s.seat, s.g, s.predictor = "", -1, nil, nil
+		s.showLobby(true)
+		s.element("lobby-entry").Get("style").Set("display", "flex")
+		s.element("lobby-room").Get("style").Set("display", "none")
+		setStatus("🌐 Online")
+		return nil
+	}))
+}
+
+// receive acts on a message from the room
+func (s *onlineSession) receive(msg netplay.Message) {
+	switch msg.Type {
+	case netplay.MsgWelcome:
+		s.room, s.seat = msg.Room, msg.Player
+		println("🏠 Joined room", s.room)
+		js.Global().Get("history").Call("replaceState", nil, "", "?online=1&room="+s.room)
+		s.element("lobby-entry").Get("style").Set("display", "none")
+		s.element("lobby-room").Get("style").Set("display", "flex")
+	case netplay.MsgLobby:
+		s.g, s.predictor, s.seat = nil, nil, msg.Player
+		s.showLobby(true)
+		s.renderLobby(msg)
+	case netplay.MsgStart:
+		s.seat, s.pack = msg.Player, msg.Pack
+		s.predictor = netplay.NewPredictor(s.seat, s.pack)
+		s.showLobby(false)
+		if s.seat >= 0 {
+			println("🎮 Game started, you are player", s.seat+1)
+		} else {
+			println("👀 Spectating the game in progress")
+		}
+	case netplay.MsgState:
+		if s.predictor == nil {
+			s.predictor = netplay.NewPredictor(s.seat, s.pack)
+		}
+		state := s.predictor.ServerState()
+		if err := s.predictor.Reconcile(msg); err != nil {
+			println("⚠️ Bad game state:", err.Error())
+			return
+		}
+		if s.g == nil || state != s.predictor.ServerState() {
+			logOnlineState(s.predictor.Game(), msg.Tick, s.seat)
+		}
+		s.g, s.lastStep = s.predictor.Game(), time.Now()
+		s.renderer.Render(s.g)
+	case netplay.MsgError:
+		println("❌ Server refused:", msg.Error)
+		s.lobbyStatus("❌ " + msg.Error)
+	}
+}
+
+// renderLobby shows the room's seats and settings. Only the host, in the
+// first seat, can change the settings.
+func (s *onlineSession) renderLobby(msg netplay.Message) {
+	s.element("lobby-room-code").Set("textContent", msg.Room)
+
+	seats := s.element("lobby-seats")
+	seats.Set("textContent", "")
+	s.ready = false
+	for i, seat := range msg.Seats {
+		text := "⏳ " + seat.Name
+		if seat.Ready {
+			text = "✅ " + seat.Name
+		}
+		if i == 0 {
+			text += " 👑"
+		}
+		if i == msg.Player {
+			text += " (you)"
+			s.ready = seat.Ready
+		}
+		item := s.document.Call("createElement", "li")
+		item.Set("textContent", text) // Names come from other players; never parse them as HTML
+		seats.Call("appendChild", item)
+	}
+
+	if settings := msg.Settings; settings != nil {
+		packs := s.element("lobby-pack")
+		packs.Set("textContent", "")
+		for _, name := range msg.Packs {
+			option := s.document.Call("createElement", "option")
+			option.Set("value", name)
+			option.Set("textContent", name)
+			packs.Call("appendChild", option)
+		}
+		packs.Set("value", settings.Pack)
+		s.element("lobby-mode").Set("value", settings.Mode)
+		s.element("lobby-max").Set("value", strconv.Itoa(settings.MaxPlayers))
+	}
+	for _, id := range []string{"lobby-pack", "lobby-mode", "lobby-max"} {
+		s.element(id).Set("disabled", msg.Player != 0)
+	}
+
+	ready := s.element("lobby-ready")
+	ready.Set("disabled", msg.Player < 0)
+	switch {
+	case msg.Player < 0:
+		ready.Set("textContent", "👀 Room full, spectating")
+	case s.ready:
+		ready.Set("textContent", "⏸️ Not Ready")
+	default:
+		ready.Set("textContent", "✅ Ready")
+	}
+
+	status := "Waiting for everyone to ready up"
+	if msg.Settings != nil && len(msg.Seats) < min(2, msg.Settings.MaxPlayers) {
+		status = "Share the code to invite another player"
+	}
+	if msg.Spectators > 0 {
+		status += " · " + strconv.Itoa(msg.Spectators) + " spectating"
+	}
+	s.lobbyStatus(status)
+	setStatus("🏠 Room " + msg.Room)
+}
+
+// sendSettings sends the host's choices in the lobby form to the room
+func (s *onlineSession) sendSettings() {
+	players, _ := strconv.Atoi(s.element("lobby-max").Get("value").String())
+	s.send(netplay.Message{Type: netplay.MsgSettings, Settings: &netplay.RoomSettings{
+		Pack:       s.element("lobby-pack").Get("value").String(),
+		Mode:       s.element("lobby-mode").Get("value").String(),
+		MaxPlayers: players,
+	}})
+}
+
+// send sends a message to the room if connected
+func (s *onlineSession) send(msg netplay.Message) {
+	if !s.connected() {
+		return
+	}
+	data, err := netplay.Encode(msg)
+	if err != nil {
+		println("❌", err.Error())
+		return
+	}
+	s.ws.Call("send", string(data))
+}
+
+// connected reports whether the connection to the room is open
+func (s *onlineSession) connected() bool {
+	return !s.ws.IsUndefined() && s.ws.Get("readyState").Int() == 1 // OPEN
+}
+
+// showLobby switches between the lobby and the board
+func (s *onlineSession) showLobby(show bool) {
+	display := "none"
+	if show {
+		display = "flex"
+	}
+	s.element("lobby").Get("style").Set("display", display)
+}
+
+// lobbyStatus shows a message under the lobby controls
+func (s *onlineSession) lobbyStatus(text string) {
+	s.element("lobby-status").Set("textContent", text)
+}
+
+// element returns the page element with the given ID
+func (s *onlineSession) element(id string) js.Value {
+	return s.document.Call("getElementById", id)
+}
+
+// onEvent calls fn whenever the element with the given ID fires event
+func (s *onlineSession) onEvent(id, event string, fn func()) {
+	s.element(id).Call("addEventListener", event, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn()
+		return nil
+	}))
+}
+
+// logOnlineState logs when the server's game changes state, as of the
+// server's tick
+func logOnlineState(g *game.Game, tick, seat int) {
+	switch g.GetState() {
+	case game.Playing:
+		if tick == 0 {
+			println("🎮 Level", g.GetLevel(), "started")
+		}
+	case game.LevelComplete:
+		println("🎉 Level", g.GetLevel(), "complete")
+	case game.GameOver, game.Victory, game.Saturated:
+		players := g.GetPlayers()
+		for _, p := range players {
+			println("   "+p.Name+":", p.Score)
+		}
+		switch winner := g.GetWinner(); {
+		case len(players) == 1:
+			println("🏁 Game over with score", g.GetScore())
+		case winner < 0:
+			println("🏁 The match is a draw")
+		case winner == seat:
+			println("🏆 You win the match!")
+		default:
+			println("🏁", players[winner].Name, "wins the match")
+		}
+	}
+}
+
+// setStatus shows a message in the game state panel
+func setStatus(text string) {
+	if el := js.Global().Get("document").Call("getElementById", "game-state"); !el.IsNull() {
+		el.Set("textContent", text)
+		el.Set("className", "paused")
+	}
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+	"github.com/nathannam/incident-commander-game/internal/netplay"
 )
 
 // HealthResponse represents the health check response
@@ -50,10 +58,49 @@ func serveIndex(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "web/index.html")
 }
 
+// loadPacks reads the JSON level packs in dir for online rooms to pick from
+func loadPacks(dir string) ([]*game.LevelPack, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	packs := make([]*game.LevelPack, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pack, err := game.LoadLevelPack(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
 func main() {
+	packDir := flag.String("packs", "", "directory of extra JSON level packs for online rooms")
+	origins := flag.String("origins", "", "comma-separated origins besides this server whose pages may join online rooms, e.g. https://play.example.com")
+	flag.Parse()
+
+	// Keep the online rooms for ?online=1 clients
+	packs, err := loadPacks(*packDir)
+	if err != nil {
+		log.Fatalf("❌ Loading level packs: %v", err)
+	}
+	rooms, err := netplay.NewRegistry(context.Background(), packs...)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
 	// Set up routes
 	http.HandleFunc("/", serveIndex)
 	http.HandleFunc("/health", healthCheckHandler)
+	http.HandleFunc("/ws", roomHandler(rooms, splitList(*origins)))
 	
 	// Serve static files with CORS headers
 	fileServer := http.FileServer(http.Dir("web/"))
@@ -65,6 +112,18 @@ func main() {
 	fmt.Println("🌐 Open http://localhost:8080 to play!")
 	fmt.Println("🔍 Health check available at http://localhost:8080/health")
 	fmt.Println("🎯 Each browser session gets its own game instance")
+	fmt.Println("🌐 Online rooms at http://localhost:8080/?online=1")
 	
 	log.Fatal(http.ListenAndServe(":8080", nil))
-}
\ No newline at end of file
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
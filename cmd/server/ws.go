@@ -0,0 +1,163 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nathannam/incident-commander-game/internal/netplay"
+)
+
+// WebSocket connection limits
+const (
+	helloWait      = 10 * time.Second  // Time a new connection has to send its hello
+	writeWait      = 5 * time.Second   // Time allowed to write a message
+	pongWait       = 30 * time.Second  // Time allowed between pongs from the client
+	pingPeriod     = pongWait * 9 / 10 // How often the client is pinged
+	maxMessageSize = 512               // Largest message accepted from a client
+)
+
+// newUpgrader returns the upgrader for room connections. Only pages served
+// by this server or from one of the allowed origins may open one, so other
+// sites cannot play in rooms from their visitors' browsers.
+func newUpgrader(origins []string) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:    1024,
+		WriteBufferSize:   4096,
+		EnableCompression: true, // Game states are verbose JSON and compress well
+		CheckOrigin:       func(r *http.Request) bool { return allowedOrigin(r, origins) },
+	}
+}
+
+// allowedOrigin reports whether the page that opened the connection is on
+// this server's host or one of the allowed origins, such as
+// https://play.example.com. Clients other than browsers send no origin and
+// are let through.
+func allowedOrigin(r *http.Request, origins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range origins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// roomHandler connects WebSocket clients to online rooms. The first message
+// must be a hello naming the player and the room to join, or no room to open
+// a new one; after that the client readies up, plays and receives the
+// room's lobby and game states.
+func roomHandler(rooms *netplay.Registry, origins []string) http.HandlerFunc {
+	upgrader := newUpgrader(origins)
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("⚠️ WebSocket upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		conn.SetReadLimit(maxMessageSize)
+
+		conn.SetReadDeadline(time.Now().Add(helloWait))
+		hello, err := readMessage(conn)
+		if err != nil || hello.Type != netplay.MsgHello {
+			writeError(conn, "expected a hello message")
+			return
+		}
+		var room *netplay.Match
+		if hello.Room == "" {
+			if room, err = rooms.Create(); err != nil {
+				writeError(conn, err.Error())
+				return
+			}
+		} else if room, _ = rooms.Find(hello.Room); room == nil {
+			writeError(conn, "no room with code "+hello.Room)
+			return
+		}
+
+		client := room.Join(hello.Name)
+		go writePump(conn, client)
+		readPump(conn, room, client)
+		room.Leave(client)
+	}
+}
+
+// readPump passes the client's messages to its room until the connection
+// fails or closes
+func readPump(conn *websocket.Conn, room *netplay.Match, client *netplay.Client) {
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		msg, err := readMessage(conn)
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("⚠️ WebSocket read failed: %v", err)
+			}
+			return
+		}
+		room.Handle(client, msg)
+	}
+}
+
+// writePump sends the room's messages to the client and keeps the
+// connection alive with pings. It closes the connection when the room
+// drops the client or a write fails, which also ends readPump.
+func writePump(conn *websocket.Conn, client *netplay.Client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+	for {
+		select {
+		case data, ok := <-client.Send():
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readMessage reads and decodes the next message from the client
+func readMessage(conn *websocket.Conn) (netplay.Message, error) {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return netplay.Message{}, err
+	}
+	return netplay.Decode(data)
+}
+
+// writeError tells the client its request was refused
+func writeError(conn *websocket.Conn, reason string) {
+	data, err := netplay.Encode(netplay.Message{Type: netplay.MsgError, Player: -1, Error: reason})
+	if err != nil {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	conn.WriteMessage(websocket.TextMessage, data)
+}
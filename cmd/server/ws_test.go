@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowedOrigin(t *testing.T) {
+	origins := []string{"https://play.example.com/"}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://game.example.org:8080", true},
+		{"https://play.example.com", true},
+		{"https://evil.example.net", false},
+		{"https://game.example.org.evil.example.net", false},
+		{"::not a url", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://game.example.org:8080/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := allowedOrigin(r, origins); got != tt.want {
+			t.Errorf("origin %q: got %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
@@ -2,4 +2,4 @@ module github.com/nathannam/incident-commander-game
 
 go 1.21
 
-require ()
\ No newline at end of file
+require github.com/gorilla/websocket v1.5.3
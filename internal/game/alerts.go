@@ -0,0 +1,168 @@
+package game
+
+// Severity ranks how urgent an alert is; Sev1 is the most critical
+type Severity int
+
+const (
+	Sev1 Severity = iota + 1 // Critical: customer-facing outage
+	Sev2                     // Major: degraded service
+	Sev3                     // Minor: needs a look soon
+)
+
+// defaultSeverityWeights is the spawn weight of Sev1, Sev2 and Sev3 alerts
+// when the level does not set severity_weights
+var defaultSeverityWeights = [3]int{1, 2, 5}
+
+// defaultAckTicks is how many ticks SEV1, SEV2 and SEV3 alerts wait to be
+// acknowledged before escalating when the level does not set ack_ticks
+var defaultAckTicks = [3]int{40, 60, 80}
+
+// outagePenalty is the score lost when an alert turns into an outage
+const outagePenalty = 50
+
+// services are the names alerts are raised against
+var services = []string{
+	"api-gateway", "auth", "checkout", "payments", "search",
+	"inventory", "db-primary", "cache", "queue", "cdn",
+}
+
+// Outage policies for alerts that escalate past SEV1
+const (
+	OutagePenalty  = "penalty"  // Lose score
+	OutageObstacle = "obstacle" // The alert's cell becomes an obstacle
+)
+
+// Alert is an alert bubble waiting on the board
+type Alert struct {
+	Position
+	Severity  Severity `json:"severity"`
+	SpawnTick int      `json:"spawn_tick"`          // Tick the alert appeared on
+	Service   string   `json:"service,omitempty"`   // Service the alert fired for
+	TicksLeft int      `json:"ticks_left"`          // Ticks of play left before the alert escalates, or becomes an outage at SEV1
+	Escalated bool     `json:"escalated,omitempty"` // Whether the alert was left long enough to escalate
+}
+
+// String returns the severity's label, e.g. "SEV1"
+func (s Severity) String() string {
+	switch s {
+	case Sev1:
+		return "SEV1"
+	case Sev2:
+		return "SEV2"
+	case Sev3:
+		return "SEV3"
+	}
+	return "SEV?"
+}
+
+// Points returns the base points for collecting an alert of this severity
+func (s Severity) Points() int {
+	switch s {
+	case Sev1:
+		return 50
+	case Sev2:
+		return 25
+	}
+	return 10
+}
+
+// severityWeights returns the current level's spawn weights per severity
+func (g *Game) severityWeights() [3]int {
+	weights := g.levelDef().SeverityWeights
+	if weights[0]+weights[1]+weights[2] <= 0 {
+		return defaultSeverityWeights
+	}
+	return weights
+}
+
+// newAlert creates an alert at pos with a severity drawn from the level's
+// spawn weights
+func (g *Game) newAlert(pos Position) Alert {
+	weights := g.severityWeights()
+	roll := g.rng.Intn(weights[0] + weights[1] + weights[2])
+	severity := Sev1
+	for _, w := range weights {
+		if roll < w {
+			break
+		}
+		roll -= w
+		severity++
+	}
+	return Alert{
+		Position:  pos,
+		Severity:  severity,
+		SpawnTick: g.Tick,
+		Service:   services[g.rng.Intn(len(services))],
+		TicksLeft: g.ackTicks(severity),
+	}
+}
+
+// ackTicks returns how long an alert of the given severity waits to be
+// acknowledged on the current level
+func (g *Game) ackTicks(s Severity) int {
+	ticks := g.levelDef().AckTicks
+	if ticks[s-1] <= 0 {
+		ticks = defaultAckTicks
+	}
+	return ticks[s-1]
+}
+
+// ageAlerts counts down the alerts' timers by one tick of play and escalates
+// the alerts whose time ran out. SEV1 alerts that run out become outages.
+// It only runs while playing, so pauses never count against an alert.
+func (g *Game) ageAlerts() {
+	kept := g.Alerts[:0]
+	outages := 0
+	for _, alert := range g.Alerts {
+		if alert.TicksLeft--; alert.TicksLeft > 0 {
+			kept = append(kept, alert)
+			continue
+		}
+		if alert.Severity > Sev1 {
+			alert.Severity--
+			alert.TicksLeft = g.ackTicks(alert.Severity)
+			alert.Escalated = true
+			g.emit(Event{Kind: EventAlertEscalated, Position: alert.Position, Severity: alert.Severity})
+			kept = append(kept, alert)
+			continue
+		}
+		outages++
+		g.setCell(alert.Position, CellEmpty)
+		g.outage(alert)
+	}
+	g.Alerts = kept
+	if outages > 0 {
+		g.spawnAlerts()
+	}
+}
+
+// outage applies the level's outage policy for an alert nobody handled:
+// either a score penalty or an obstacle where the alert stood
+func (g *Game) outage(alert Alert) {
+	if g.levelDef().Outage == OutageObstacle && !g.hitsMovingObstacle(alert.Position) {
+		g.emit(Event{Kind: EventOutage, Position: alert.Position})
+		g.placeObstacle(alert.Position)
+		return
+	}
+	// Every commander still in the match pays the penalty
+	players := g.active()
+	total := 0
+	for _, p := range players {
+		total += min(outagePenalty, p.Score)
+	}
+	g.emit(Event{Kind: EventOutage, Position: alert.Position, Points: total})
+	for _, p := range players {
+		if penalty := min(outagePenalty, p.Score); penalty > 0 {
+			g.addScore(p, -penalty, ScoreOutage)
+		}
+	}
+}
+
+// alertPositions returns the positions of the alerts on the board
+func (g *Game) alertPositions() []Position {
+	positions := make([]Position, len(g.Alerts))
+	for i, alert := range g.Alerts {
+		positions[i] = alert.Position
+	}
+	return positions
+}
@@ -0,0 +1,32 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlertsWaitOutPauses(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	g := New(20, 20, WithSeed(3), WithClock(clock))
+	before := append([]Alert(nil), g.GetAlerts()...)
+
+	g.Step([]Command{PauseCommand()})
+	for i := 0; i < 200; i++ {
+		clock.Advance(100 * time.Millisecond)
+		if result := g.Step(nil); len(result.Events) > 0 {
+			t.Fatalf("tick %d: paused game emitted %v", result.Tick, result.Events)
+		}
+	}
+	g.Apply([]Command{PauseCommand()})
+	result := g.Step(nil)
+	for _, e := range result.Events {
+		if e.Kind == EventAlertEscalated || e.Kind == EventOutage {
+			t.Fatalf("alert escalated on the first tick after the pause: %+v", e)
+		}
+	}
+	for i, alert := range g.GetAlerts() {
+		if alert.Position == before[i].Position && alert.TicksLeft != before[i].TicksLeft-1 {
+			t.Errorf("alert %d has %d ticks left after one tick of play, want %d", i, alert.TicksLeft, before[i].TicksLeft-1)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// benchBoardSize is large enough for the longest trail benchmarked
+const benchBoardSize = 400
+
+// benchTrailLengths are the trail lengths each benchmark runs at. Backed by
+// the occupancy grid, collisions and steps should cost the same no matter
+// how long the trail is.
+var benchTrailLengths = []int{100, 1000, 10000, 50000}
+
+// BenchmarkCheckCollisions checks the commander against random empty cells
+// of a board holding a long trail, which is the full collision check a
+// commander that survives its move goes through
+func BenchmarkCheckCollisions(b *testing.B) {
+	for _, length := range benchTrailLengths {
+		b.Run(fmt.Sprintf("trail=%d", length), func(b *testing.B) {
+			g := playTrail(length)
+			p := g.Players[0]
+			rng := rand.New(rand.NewSource(1))
+			cells := make([]Position, 0, 1024)
+			for len(cells) < cap(cells) {
+				pos := Position{X: rng.Intn(benchBoardSize), Y: rng.Intn(benchBoardSize)}
+				if g.CellAt(pos) == CellEmpty && !g.isCommander(pos) {
+					cells = append(cells, pos)
+				}
+			}
+			home := p.Commander
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var result PlayerStep
+				p.Commander = cells[i%len(cells)]
+				g.checkCollisions(p, &result)
+			}
+			b.StopTimer()
+			if p.Commander = home; p.Out {
+				b.Fatal("commander collided with an empty cell")
+			}
+		})
+	}
+}
+
+// BenchmarkStep steps a game whose trail is already the given length. When
+// the commander runs out of board the game is set up again off the clock.
+func BenchmarkStep(b *testing.B) {
+	for _, length := range benchTrailLengths {
+		b.Run(fmt.Sprintf("trail=%d", length), func(b *testing.B) {
+			g := playTrail(length)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if g.GetState() != Playing || g.GetCommander().Y >= benchBoardSize-2 {
+					b.StopTimer()
+					g = playTrail(length)
+					b.StartTimer()
+				}
+				g.Step([]Command{DirectionCommand(serpentine(g))})
+			}
+		})
+	}
+}
+
+// playTrail returns a game on an empty board whose commander has snaked
+// back and forth until its trail is the given length
+func playTrail(length int) *Game {
+	pack := &LevelPack{Name: "Benchmark", Levels: []Level{{
+		Name:           "Open Floor",
+		Width:          benchBoardSize,
+		Height:         benchBoardSize,
+		TickIntervalMS: 1,
+		AlertsNeeded:   1 << 30,
+		AlertsOnScreen: 3,
+	}}}
+	g := New(benchBoardSize, benchBoardSize,
+		WithSeed(1),
+		WithClock(NewManualClock(time.Unix(0, 0))),
+		WithLevelPack(pack))
+	for len(g.GetTrail()) < length && g.GetState() == Playing {
+		g.Step([]Command{DirectionCommand(serpentine(g))})
+	}
+	return g
+}
+
+// serpentine steers the commander along alternate rows from its spawn row
+// down, so it never crosses its own trail
+func serpentine(g *Game) Direction {
+	c := g.GetCommander()
+	if (c.Y-benchBoardSize/2)%2 == 0 {
+		if c.X < benchBoardSize-1 {
+			return Right
+		}
+		return Down
+	}
+	if c.X > 0 {
+		return Left
+	}
+	return Down
+}
@@ -0,0 +1,213 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BreakerPhase is the state of a circuit breaker wall
+type BreakerPhase int
+
+const (
+	BreakerOpen    BreakerPhase = iota // Passable
+	BreakerWarning                     // Still passable, about to close
+	BreakerClosed                      // Solid
+)
+
+// BreakerWall is a wall segment that cycles open, warning and closed on a
+// tick timer
+type BreakerWall struct {
+	Cells        []Position   `json:"cells"`
+	OpenTicks    int          `json:"open_ticks"`
+	WarningTicks int          `json:"warning_ticks"`
+	ClosedTicks  int          `json:"closed_ticks"`
+	Phase        BreakerPhase `json:"phase"`
+	Remaining    int          `json:"remaining"` // Ticks left in the current phase
+}
+
+// BreakerSpec declares a circuit breaker wall in a level layout
+type BreakerSpec struct {
+	Cells   [][2]int `json:"cells"`
+	Open    int      `json:"open"`             // Ticks the wall stays open
+	Warning int      `json:"warning"`          // Ticks of warning before closing
+	Closed  int      `json:"closed"`           // Ticks the wall stays closed
+	Offset  int      `json:"offset,omitempty"` // Ticks into the cycle at level start
+}
+
+// newBreakerWall creates an open wall that is offset ticks into its cycle
+func newBreakerWall(cells []Position, open, warning, closed, offset int) BreakerWall {
+	b := BreakerWall{
+		Cells:        cells,
+		OpenTicks:    max(1, open),
+		WarningTicks: max(1, warning),
+		ClosedTicks:  max(1, closed),
+		Phase:        BreakerOpen,
+	}
+	b.Remaining = b.OpenTicks
+	cycle := b.OpenTicks + b.WarningTicks + b.ClosedTicks
+	for i := 0; i < offset%cycle; i++ {
+		b.advance()
+	}
+	return b
+}
+
+// advance moves the wall one tick through its cycle
+func (b *BreakerWall) advance() {
+	if b.Remaining--; b.Remaining > 0 {
+		return
+	}
+	switch b.Phase {
+	case BreakerOpen:
+		b.Phase, b.Remaining = BreakerWarning, b.WarningTicks
+	case BreakerWarning:
+		b.Phase, b.Remaining = BreakerClosed, b.ClosedTicks
+	case BreakerClosed:
+		b.Phase, b.Remaining = BreakerOpen, b.OpenTicks
+	}
+}
+
+// covers reports whether pos is one of the wall's cells
+func (b *BreakerWall) covers(pos Position) bool {
+	for _, c := range b.Cells {
+		if c == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// updateBreakers advances every breaker wall by one tick. A wall never closes
+// on top of the commander or a moving obstacle: it stays in its warning phase
+// until the cells are clear. Alerts caught by a closing wall are respawned.
+func (g *Game) updateBreakers() {
+	for i := range g.Breakers {
+		b := &g.Breakers[i]
+		if b.Phase == BreakerWarning && b.Remaining == 1 && !g.breakerClear(b) {
+			continue
+		}
+		b.advance()
+		if b.Phase == BreakerClosed && b.Remaining == b.ClosedTicks {
+			g.evictAlerts(b)
+		}
+	}
+}
+
+// breakerClear reports whether nothing that must not be crushed is inside
+// the wall's cells
+func (g *Game) breakerClear(b *BreakerWall) bool {
+	for _, p := range g.active() {
+		if b.covers(p.Commander) {
+			return false
+		}
+	}
+	for _, m := range g.MovingObstacles {
+		if b.covers(m.Position) {
+			return false
+		}
+	}
+	return true
+}
+
+// evictAlerts removes alerts inside a freshly closed wall and spawns
+// replacements elsewhere
+func (g *Game) evictAlerts(b *BreakerWall) {
+	kept := g.Alerts[:0]
+	for _, alert := range g.Alerts {
+		if !b.covers(alert.Position) {
+			kept = append(kept, alert)
+		} else {
+			g.setCell(alert.Position, CellEmpty)
+		}
+	}
+	if len(kept) != len(g.Alerts) {
+		g.Alerts = kept
+		g.spawnAlerts()
+	}
+}
+
+// hitsClosedBreaker reports whether pos is inside a closed breaker wall
+func (g *Game) hitsClosedBreaker(pos Position) bool {
+	for i := range g.Breakers {
+		if g.Breakers[i].Phase == BreakerClosed && g.Breakers[i].covers(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// hitsArmedBreaker reports whether pos is inside a closed wall or one about
+// to close, where new alerts must not spawn
+func (g *Game) hitsArmedBreaker(pos Position) bool {
+	for i := range g.Breakers {
+		if g.Breakers[i].Phase != BreakerOpen && g.Breakers[i].covers(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// addBreakerWall adds a breaker if its cells stay on the board, outside the
+// spawn safe zone and off static obstacles and other breakers
+func (g *Game) addBreakerWall(b BreakerWall) bool {
+	if len(b.Cells) == 0 {
+		return false
+	}
+	for _, p := range b.Cells {
+		if p.X < 0 || p.X >= g.Width || p.Y < 0 || p.Y >= g.Height || g.inSpawnZone(p) || g.CellAt(p) == CellObstacle {
+			return false
+		}
+		for i := range g.Breakers {
+			if g.Breakers[i].covers(p) {
+				return false
+			}
+		}
+	}
+	g.Breakers = append(g.Breakers, b)
+	return true
+}
+
+// addBreakers adds count randomly placed straight breaker walls of the given
+// length with staggered cycles
+func (g *Game) addBreakers(count, length, open, warning, closed int) {
+	cycle := open + warning + closed
+	for i := 0; i < count; i++ {
+		for attempts := 0; attempts < 50; attempts++ {
+			start := Position{X: g.rng.Intn(g.Width), Y: g.rng.Intn(g.Height)}
+			horizontal := g.rng.Intn(2) == 0
+			cells := make([]Position, length)
+			for j := range cells {
+				cells[j] = start
+				if horizontal {
+					cells[j].X += j
+				} else {
+					cells[j].Y += j
+				}
+			}
+			offset := i * cycle / max(1, count)
+			if g.addBreakerWall(newBreakerWall(cells, open, warning, closed, offset)) {
+				break
+			}
+		}
+	}
+}
+
+// parse converts the spec's cells
+func (spec BreakerSpec) parse() ([]Position, error) {
+	if len(spec.Cells) == 0 {
+		return nil, errors.New("breaker wall needs at least one cell")
+	}
+	if spec.Open <= 0 || spec.Warning <= 0 || spec.Closed <= 0 {
+		return nil, errors.New("breaker open, warning and closed ticks must be positive")
+	}
+	cells := make([]Position, len(spec.Cells))
+	for i, c := range spec.Cells {
+		if c[0] < 0 || c[1] < 0 {
+			return nil, fmt.Errorf("breaker cell %v is outside the board", c)
+		}
+		cells[i] = Position{X: c[0], Y: c[1]}
+	}
+	return cells, nil
+}
+
+// GetBreakers returns the level's circuit breaker walls
+func (g *Game) GetBreakers() []BreakerWall { return g.Breakers }
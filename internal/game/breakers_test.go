@@ -0,0 +1,82 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerWaitsForCommander(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	g := New(20, 20, WithSeed(1), WithClock(clock), WithLevelPack(openPack(20, 20)))
+	placeAlerts(g, Position{X: 18, Y: 18})
+
+	// The commander spawns at (10, 10) heading right, straight along a wall
+	// due to close while it is still driving over it
+	wall := newBreakerWall([]Position{{X: 11, Y: 10}, {X: 12, Y: 10}, {X: 13, Y: 10}, {X: 14, Y: 10}}, 20, 4, 12, 0)
+	wall.Phase, wall.Remaining = BreakerWarning, 2
+	g.Breakers = []BreakerWall{wall}
+
+	for i := 0; i < 8; i++ {
+		clock.Advance(g.GetTickInterval())
+		result := g.Step(nil)
+		if result.Collision != CollisionNone {
+			t.Fatalf("tick %d: commander at %v collided with %v", result.Tick, g.GetCommander(), result.Collision)
+		}
+		if b := g.Breakers[0]; b.Phase == BreakerClosed && b.covers(result.From) {
+			t.Fatalf("tick %d: wall closed with the commander on %v", result.Tick, result.From)
+		}
+	}
+	if g.Breakers[0].Phase != BreakerClosed {
+		t.Fatalf("wall is %v once the commander left it, want closed", g.Breakers[0].Phase)
+	}
+}
+
+func TestAlertsAvoidArmedBreakers(t *testing.T) {
+	// Walls across the top half of the board, one closed and one warning
+	var closed, warning []Position
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 20; x++ {
+			closed = append(closed, Position{X: x, Y: y})
+			warning = append(warning, Position{X: x, Y: y + 5})
+		}
+	}
+	for _, policy := range []string{SpawnUniform, SpawnAway, SpawnHotspots, SpawnMinDistance} {
+		pack := openPack(20, 20)
+		pack.Levels[0].Spawn.Policy = policy
+		g := New(20, 20, WithSeed(1), WithClock(NewManualClock(time.Unix(0, 0))), WithLevelPack(pack))
+		shut := newBreakerWall(closed, 20, 4, 12, 0)
+		shut.Phase, shut.Remaining = BreakerClosed, 12
+		closing := newBreakerWall(warning, 20, 4, 12, 0)
+		closing.Phase, closing.Remaining = BreakerWarning, 4
+		g.Breakers = []BreakerWall{shut, closing}
+
+		for i := 0; i < 500; i++ {
+			pos, ok := g.pickSpawnCell()
+			if !ok {
+				t.Fatalf("%s: no cell to spawn on", policy)
+			}
+			if g.hitsArmedBreaker(pos) {
+				t.Fatalf("%s: alert spawned inside a breaker wall at %v", policy, pos)
+			}
+		}
+	}
+}
+
+func TestClosingBreakerEvictsAlerts(t *testing.T) {
+	g := New(20, 20, WithSeed(1), WithClock(NewManualClock(time.Unix(0, 0))), WithLevelPack(openPack(20, 20)))
+	placeAlerts(g, Position{X: 3, Y: 3})
+	wall := newBreakerWall([]Position{{X: 3, Y: 2}, {X: 3, Y: 3}, {X: 3, Y: 4}}, 20, 4, 12, 0)
+	wall.Phase, wall.Remaining = BreakerWarning, 1
+	g.Breakers = []BreakerWall{wall}
+
+	g.Step(nil)
+	if g.Breakers[0].Phase != BreakerClosed {
+		t.Fatalf("wall is %v, want closed", g.Breakers[0].Phase)
+	}
+	if len(g.Alerts) != 1 {
+		t.Fatalf("%d alerts on the board, want the evicted one respawned", len(g.Alerts))
+	}
+	if pos := g.Alerts[0].Position; g.Breakers[0].covers(pos) || g.CellAt(Position{X: 3, Y: 3}) == CellAlert {
+		t.Fatalf("alert left inside the closed wall at %v", pos)
+	}
+}
@@ -0,0 +1,104 @@
+package game
+
+// defaultComboWindow is how many ticks a combo survives without a pickup when
+// the level does not set combo_window_ticks
+const defaultComboWindow = 40
+
+// ComboBreak explains why a combo ended
+type ComboBreak int
+
+const (
+	ComboNearMiss ComboBreak = iota // Passed right next to a wall, trail or obstacle
+	ComboTimeout                    // Went too long without collecting an alert
+)
+
+// comboWindow returns the number of ticks a combo lasts without a pickup
+func (g *Game) comboWindow() int {
+	if window := g.levelDef().ComboWindowTicks; window > 0 {
+		return window
+	}
+	return defaultComboWindow
+}
+
+// extendCombo counts a clean pickup by the player and returns the
+// multiplier it earns
+func (g *Game) extendCombo(p *Player) int {
+	p.Combo++
+	p.ComboTicksLeft = g.comboWindow()
+	return p.Combo
+}
+
+// breakCombo ends the player's current combo, if any
+func (g *Game) breakCombo(p *Player, reason ComboBreak) {
+	if p.Combo == 0 {
+		return
+	}
+	g.emit(Event{Kind: EventComboBroken, Player: p.ID, Combo: p.Combo, ComboBreak: reason})
+	p.Combo = 0
+	p.ComboTicksLeft = 0
+}
+
+// decayCombo counts down the player's combo window and breaks the combo
+// once it runs out
+func (g *Game) decayCombo(p *Player) {
+	if p.Combo == 0 {
+		return
+	}
+	p.ComboTicksLeft--
+	if p.ComboTicksLeft <= 0 {
+		g.breakCombo(p, ComboTimeout)
+	}
+}
+
+// checkNearMiss breaks the player's combo when its commander ends its move
+// right next to a wall, a trail or an obstacle. The cell it just left does
+// not count.
+func (g *Game) checkNearMiss(p *Player, result *PlayerStep) {
+	var from Position
+	if len(p.Trail) > 0 {
+		from = p.Trail[len(p.Trail)-1]
+	}
+	for _, n := range neighbours(p.Commander) {
+		if g.GetEdges() == EdgeWrap {
+			n = wrap(n, g.Width, g.Height)
+		}
+		if n == from || !g.isHazard(n) {
+			continue
+		}
+		result.NearMiss = true
+		g.emit(Event{Kind: EventNearMiss, Player: p.ID, Position: n})
+		g.breakCombo(p, ComboNearMiss)
+		return
+	}
+}
+
+// isHazard reports whether moving into p would end the game. Off-board
+// cells are only hazards with solid edges.
+func (g *Game) isHazard(p Position) bool {
+	if !inBounds(p, g.Width, g.Height) {
+		switch g.GetEdges() {
+		case EdgeWrap:
+			p = wrap(p, g.Width, g.Height)
+		case EdgeBounce:
+			return false
+		default:
+			return true
+		}
+	}
+	if cell := g.CellAt(p); cell == CellTrail || cell == CellObstacle {
+		return true
+	}
+	return g.hitsMovingObstacle(p) || g.hitsClosedBreaker(p)
+}
+
+// GetCombo returns the number of alerts the first player collected in a row
+// without a near miss; the next alert is worth Combo+1 times its base points
+func (g *Game) GetCombo() int { return g.Players[0].Combo }
+
+// GetComboTicksLeft returns how many ticks remain before the first player's
+// combo decays
+func (g *Game) GetComboTicksLeft() int { return g.Players[0].ComboTicksLeft }
+
+// GetComboWindow returns how many ticks a combo lasts without a pickup on
+// the current level
+func (g *Game) GetComboWindow() int { return g.comboWindow() }
@@ -0,0 +1,48 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComboDecayAndNearMiss(t *testing.T) {
+	// The commander spawns at (50, 10) heading right and collects alerts at
+	// (51, 10) and (52, 10) on the first two ticks, for a combo of 2
+	tests := []struct {
+		name      string
+		window    int
+		obstacles []Position
+		ticks     int
+		want      int
+	}{
+		{name: "lasts the window", window: 5, ticks: 6, want: 2},
+		{name: "decays after the window", window: 5, ticks: 7, want: 0},
+		{name: "lasts the default window", ticks: 2 + defaultComboWindow - 1, want: 2},
+		{name: "decays after the default window", ticks: 2 + defaultComboWindow, want: 0},
+		{name: "clear of a wall", window: 20, obstacles: []Position{{X: 53, Y: 8}, {X: 54, Y: 8}, {X: 55, Y: 8}}, ticks: 6, want: 2},
+		{name: "alongside a wall", window: 20, obstacles: []Position{{X: 53, Y: 9}, {X: 54, Y: 9}, {X: 55, Y: 9}}, ticks: 6, want: 0},
+		{name: "near miss on a pickup starts over", window: 20, obstacles: []Position{{X: 52, Y: 11}}, ticks: 2, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack := openPack(100, 20)
+			pack.Levels[0].ComboWindowTicks = tt.window
+			clock := NewManualClock(time.Unix(0, 0))
+			g := New(100, 20, WithSeed(1), WithClock(clock), WithLevelPack(pack))
+			placeAlerts(g, Position{X: 51, Y: 10}, Position{X: 52, Y: 10}, Position{X: 90, Y: 2})
+			for _, pos := range tt.obstacles {
+				g.addObstacle(pos)
+			}
+
+			for i := 0; i < tt.ticks; i++ {
+				clock.Advance(g.GetTickInterval())
+				if result := g.Step(nil); result.Collision != CollisionNone {
+					t.Fatalf("tick %d: commander collided with %v", result.Tick, result.Collision)
+				}
+			}
+			if got := g.GetCombo(); got != tt.want {
+				t.Errorf("combo after %d ticks = %d, want %d", tt.ticks, got, tt.want)
+			}
+		})
+	}
+}
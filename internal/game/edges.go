@@ -0,0 +1,96 @@
+package game
+
+import "fmt"
+
+// EdgePolicy decides what happens when the commander reaches the board edge
+type EdgePolicy int
+
+const (
+	EdgeSolid  EdgePolicy = iota // Hitting the edge ends the game
+	EdgeWrap                     // Leaving one edge enters from the opposite one
+	EdgeBounce                   // The commander turns along the edge instead
+)
+
+// String returns the policy's name as used in level packs and URLs
+func (e EdgePolicy) String() string {
+	switch e {
+	case EdgeWrap:
+		return "wrap"
+	case EdgeBounce:
+		return "bounce"
+	}
+	return "solid"
+}
+
+// ParseEdgePolicy parses a policy name as returned by EdgePolicy.String. An
+// empty name means solid edges.
+func ParseEdgePolicy(name string) (EdgePolicy, error) {
+	switch name {
+	case "", "solid":
+		return EdgeSolid, nil
+	case "wrap":
+		return EdgeWrap, nil
+	case "bounce":
+		return EdgeBounce, nil
+	}
+	return EdgeSolid, fmt.Errorf("game: unknown edge policy %q", name)
+}
+
+// GetEdges returns the edge policy in force on the current level: the one
+// set with WithEdges, or else the level's own
+func (g *Game) GetEdges() EdgePolicy {
+	if g.cfg.hasEdges {
+		return g.cfg.edges
+	}
+	edges, _ := ParseEdgePolicy(g.levelDef().Edges)
+	return edges
+}
+
+// inBounds reports whether p is on a width x height board
+func inBounds(p Position, width, height int) bool {
+	return p.X >= 0 && p.X < width && p.Y >= 0 && p.Y < height
+}
+
+// wrap maps p onto a width x height toroidal board
+func wrap(p Position, width, height int) Position {
+	return Position{X: (p.X%width + width) % width, Y: (p.Y%height + height) % height}
+}
+
+// nextPosition returns where the player's commander moves this tick under
+// the edge policy, turning it first when it bounces off an edge
+func (g *Game) nextPosition(p *Player) Position {
+	next := p.Commander.step(p.Direction)
+	if inBounds(next, g.Width, g.Height) {
+		return next
+	}
+	switch g.GetEdges() {
+	case EdgeWrap:
+		return wrap(next, g.Width, g.Height)
+	case EdgeBounce:
+		p.Direction = g.bounceDirection(p)
+		return p.Commander.step(p.Direction)
+	}
+	return next
+}
+
+// bounceDirection picks the direction to turn to at an edge: a safe
+// perpendicular turn, clockwise first, or else whichever turn stays on the
+// board
+func (g *Game) bounceDirection(p *Player) Direction {
+	turns := [2]Direction{Up, Down}
+	if p.Direction == Up || p.Direction == Down {
+		turns = [2]Direction{Right, Left}
+	}
+	if p.Direction == Down || p.Direction == Right {
+		turns[0], turns[1] = turns[1], turns[0]
+	}
+	for _, dir := range turns {
+		if next := p.Commander.step(dir); inBounds(next, g.Width, g.Height) && !g.isHazard(next) {
+			return dir
+		}
+	}
+	if inBounds(p.Commander.step(turns[0]), g.Width, g.Height) {
+		return turns[0]
+	}
+	return turns[1]
+}
@@ -0,0 +1,113 @@
+package game
+
+// EventKind identifies the type of a gameplay Event
+type EventKind int
+
+const (
+	EventAlertCollected EventKind = iota
+	EventAlertSpawned
+	EventCollision
+	EventLevelCompleted
+	EventLevelStarted
+	EventGameOver
+	EventPaused
+	EventResumed
+	EventScoreChanged
+	EventVictory
+	EventNearMiss
+	EventComboBroken
+	EventAlertEscalated
+	EventOutage
+	EventBoardSaturated
+)
+
+// ScoreReason explains why the score changed
+type ScoreReason int
+
+const (
+	ScoreAlert ScoreReason = iota
+	ScoreLevelBonus
+	ScoreTimeBonus
+	ScoreOutage // Negative: an alert escalated into an outage
+)
+
+// Event describes something that just happened in the game. Which fields are
+// set depends on Kind; Player is the ID of the player concerned:
+//
+//	EventAlertCollected: Player, Position, Severity, Points
+//	EventAlertSpawned:   Position, Severity
+//	EventCollision:      Player (now out), Position, Collision
+//	EventLevelCompleted: Level, Points (completion plus time bonus)
+//	EventLevelStarted:   Level
+//	EventGameOver:       Level, Score, Player (the winner, -1 if none)
+//	EventScoreChanged:   Player, Points (delta), Score (the player's new total), Reason
+//	EventVictory:        Level, Score, Player (the winner, -1 if none)
+//	EventNearMiss:       Player, Position (the hazard the commander passed)
+//	EventComboBroken:    Player, Combo (the combo lost), ComboBreak
+//	EventAlertEscalated: Position, Severity (the new severity)
+//	EventOutage:         Position, Points (score lost, 0 when it left an obstacle)
+//	EventBoardSaturated: Level, Score
+type Event struct {
+	Kind       EventKind
+	Tick       int
+	Player     int
+	Position   Position
+	Severity   Severity
+	Collision  CollisionKind
+	Level      int
+	Points     int
+	Score      int
+	Reason     ScoreReason
+	Combo      int
+	ComboBreak ComboBreak
+}
+
+// listener is a subscribed event callback
+type listener struct {
+	id int
+	fn func(Event)
+}
+
+// eventBus delivers events to subscribers and collects them for Step
+type eventBus struct {
+	listeners []listener
+	nextID    int
+	stepping  bool    // Whether events are being collected for a StepResult
+	collected []Event // Events emitted during the current Step
+}
+
+// Subscribe registers fn to be called for every event and returns a function
+// that removes the subscription. Subscriptions survive Restart.
+func (g *Game) Subscribe(fn func(Event)) (unsubscribe func()) {
+	g.bus.nextID++
+	id := g.bus.nextID
+	g.bus.listeners = append(g.bus.listeners, listener{id: id, fn: fn})
+
+	return func() {
+		for i, l := range g.bus.listeners {
+			if l.id == id {
+				g.bus.listeners = append(g.bus.listeners[:i], g.bus.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit stamps the event with the current tick and delivers it
+func (g *Game) emit(e Event) {
+	e.Tick = g.Tick
+	if g.bus.stepping {
+		g.bus.collected = append(g.bus.collected, e)
+	}
+	for _, l := range g.bus.listeners {
+		l.fn(e)
+	}
+}
+
+// addScore changes the player's score, and with it the game's, and reports
+// why
+func (g *Game) addScore(p *Player, points int, reason ScoreReason) {
+	p.Score += points
+	g.Score += points
+	g.emit(Event{Kind: EventScoreChanged, Player: p.ID, Points: points, Score: p.Score, Reason: reason})
+}
@@ -28,137 +28,166 @@ const (
 	Paused
 	GameOver
 	LevelComplete
+	Victory   // Every level cleared; see GetSummary
+	Saturated // No free cell left for the alerts the level needs
 )
 
+// baseBoardSize is the board size generator parameters are tuned for
+const baseBoardSize = 20
+
 // Game represents the main game structure
 type Game struct {
 	Width, Height    int
-	Commander        Position
-	Trail            []Position
-	Alerts           []Position
+	Players          []*Player // Every commander on the board, by ID
+	Alerts           []Alert
 	Obstacles        []Position
-	Direction        Direction
+	MovingObstacles  []MovingObstacle
+	Breakers         []BreakerWall
+	SpeedModifiers   []SpeedModifier // Active boosts and slow-downs
+	Results          []LevelResult   // Completed levels, in order
 	State            GameState
-	Score            int
+	Score            int // Points scored by all players together
+	Winner           int // ID of the player who won the match, or -1
 	Level            int
 	AlertsCollected  int
 	AlertsNeeded     int
 	StartTime        time.Time
 	LastUpdate       time.Time
 	LevelCompleteTime time.Time // Time when level was completed
+	Tick              int       // Number of ticks stepped since the game started
+
+	cfg config      // Settings the game was created with, reused by Restart
+	src *splitMix64 // State behind rng, saved by Marshal
+	rng *rand.Rand  // Per-game random source for all spawns and layouts
+	bus eventBus    // Event subscribers, kept across Restart
+	grid []Cell     // Occupancy of every board cell, see CellAt
+	free freeCells  // Empty cells of grid, for spawning
+	endlessDef *Level // Current level when it lies past the end of the pack, see levelDef
 }
 
 // New creates a new game instance
-func New(width, height int) *Game {
-	rand.Seed(time.Now().UnixNano())
+func New(width, height int, opts ...Option) *Game {
+	return newGame(width, height, newConfig(opts))
+}
+
+// newGame creates a game from an already resolved config
+func newGame(width, height int, cfg config) *Game {
+	src := newSource(cfg.seed)
 	
 	g := &Game{
 		Width:     width,
 		Height:    height,
-		Commander: Position{X: width / 2, Y: height / 2},
-		Trail:     make([]Position, 0),
-		Alerts:    make([]Position, 0),
+		Players:   newPlayers(cfg.players),
+		Alerts:    make([]Alert, 0),
 		Obstacles: make([]Position, 0),
-		Direction: Right,
 		State:     Playing,
+		Winner:    -1,
 		Score:     0,
 		Level:     1,
 		AlertsCollected: 0,
-		AlertsNeeded:      5,
-		StartTime:         time.Now(),
-		LastUpdate:        time.Now(),
+		StartTime:         cfg.clock.Now(),
+		LastUpdate:        cfg.clock.Now(),
 		LevelCompleteTime: time.Time{}, // Initialize to zero time
+		cfg:               cfg,
+		src:               src,
+		rng:               rand.New(src),
 	}
 	
-	g.spawnAlerts()
-	g.setupLevel()
+	g.startLevel()
 	
 	return g
 }
 
-// Update updates the game state
+// Update advances the game by one tick
 func (g *Game) Update() {
-	now := time.Now()
-	g.LastUpdate = now
-
-	// Always check level completion for timer-based transitions
-	g.checkLevelComplete()
-
-	// Only move and check collisions when playing
-	if g.State != Playing {
-		return
-	}
-
-	// Move commander
-	g.moveCommander()
-	
-	// Check collisions
-	g.checkCollisions()
+	g.Step(nil)
 }
 
-// moveCommander moves the commander in the current direction
-func (g *Game) moveCommander() {
+// moveCommander applies the player's next queued turn and moves its
+// commander
+func (g *Game) moveCommander(p *Player) {
+	g.applyTurn(p)
+	
 	// Add current position to trail
-	g.Trail = append(g.Trail, g.Commander)
+	p.Trail = append(p.Trail, p.Commander)
+	g.setCell(p.Commander, CellTrail)
 	
-	// Move commander
-	switch g.Direction {
-	case Up:
-		g.Commander.Y--
-	case Down:
-		g.Commander.Y++
-	case Left:
-		g.Commander.X--
-	case Right:
-		g.Commander.X++
-	}
+	// Classic mode keeps only as much trail as the alerts collected earned
+	g.trimTrail(p)
+	
+	// Move commander, wrapping or bouncing at the board edge
+	p.Commander = g.nextPosition(p)
+	p.LastMoved = p.Direction
 }
 
-// checkCollisions checks for wall, trail, and alert collisions
-func (g *Game) checkCollisions() {
-	// Wall collision
-	if g.Commander.X < 0 || g.Commander.X >= g.Width ||
-		g.Commander.Y < 0 || g.Commander.Y >= g.Height {
-		g.State = GameOver
+// checkCollisions checks the player's commander for wall, trail, and alert
+// collisions and records the outcome in result. Every trail counts, the
+// player's own and everyone else's.
+func (g *Game) checkCollisions(p *Player, result *PlayerStep) {
+	// Wall collision; wrapping and bouncing edges keep the commander on the board
+	if p.Commander.X < 0 || p.Commander.X >= g.Width ||
+		p.Commander.Y < 0 || p.Commander.Y >= g.Height {
+		g.collide(p, CollisionWall, result)
 		return
 	}
 	
-	// Trail collision (self-collision)
-	for _, segment := range g.Trail {
-		if g.Commander.X == segment.X && g.Commander.Y == segment.Y {
-			g.State = GameOver
-			return
-		}
+	// Trail and obstacle collisions are a single occupancy grid lookup
+	switch g.CellAt(p.Commander) {
+	case CellTrail:
+		g.collide(p, CollisionTrail, result)
+		return
+	case CellObstacle:
+		g.collide(p, CollisionObstacle, result)
+		return
 	}
-	
-	// Obstacle collision
-	for _, obstacle := range g.Obstacles {
-		if g.Commander.X == obstacle.X && g.Commander.Y == obstacle.Y {
-			g.State = GameOver
-			return
-		}
+	if g.hitsMovingObstacle(p.Commander) || g.hitsClosedBreaker(p.Commander) {
+		g.collide(p, CollisionObstacle, result)
+		return
 	}
 	
-	// Alert collision
-	for i, alert := range g.Alerts {
-		if g.Commander.X == alert.X && g.Commander.Y == alert.Y {
-			g.collectAlert(i)
-			break
+	// Near misses break the combo before a pickup on the same tick is scored
+	g.checkNearMiss(p, result)
+	
+	// Alert collision; only a handful of alerts are ever on the board
+	if g.CellAt(p.Commander) == CellAlert {
+		for i, alert := range g.Alerts {
+			if alert.Position == p.Commander {
+				result.Collected = true
+				result.CollectedAt = alert.Position
+				g.collectAlert(p, i)
+				break
+			}
 		}
 	}
+	if !result.Collected {
+		g.decayCombo(p)
+	}
+}
+
+// collide knocks the player out after its commander hits something; the
+// game ends once checkMatchOver finds the match decided
+func (g *Game) collide(p *Player, kind CollisionKind, result *PlayerStep) {
+	p.Out = true
+	result.Collision = kind
+	g.emit(Event{Kind: EventCollision, Player: p.ID, Position: p.Commander, Collision: kind})
 }
 
-// collectAlert handles alert collection
-func (g *Game) collectAlert(index int) {
+// collectAlert handles alert collection by the player
+func (g *Game) collectAlert(p *Player, index int) {
 	// Remove the collected alert
+	alert := g.Alerts[index]
 	g.Alerts = append(g.Alerts[:index], g.Alerts[index+1:]...)
+	g.setCell(alert.Position, CellEmpty)
 	
-	// Increase score
-	basePoints := 10
-	comboMultiplier := g.AlertsCollected + 1
-	g.Score += basePoints * comboMultiplier
+	// Increase score; more severe alerts are worth more
+	basePoints := alert.Severity.Points()
+	comboMultiplier := g.extendCombo(p)
+	points := basePoints * comboMultiplier * g.cfg.mode.rules().pointsPercent / 100
+	g.emit(Event{Kind: EventAlertCollected, Player: p.ID, Position: alert.Position, Severity: alert.Severity, Points: points})
+	g.addScore(p, points, ScoreAlert)
 	
 	g.AlertsCollected++
+	p.Collected++
 	
 	// Spawn a new alert
 	g.spawnAlerts()
@@ -166,19 +195,27 @@ func (g *Game) collectAlert(index int) {
 
 // checkLevelComplete checks if the level is complete
 func (g *Game) checkLevelComplete() {
-	if g.AlertsCollected >= g.AlertsNeeded {
+	if g.AlertsCollected >= g.AlertsNeeded && g.State != Victory {
 		if g.State != LevelComplete {
 			g.State = LevelComplete
 			
 			// Level completion bonus
-			timeBonus := max(0, 60-int(time.Since(g.StartTime).Seconds()))
-			g.Score += (100 * g.Level) + timeBonus
+			timeBonus := max(0, 60-int(g.since(g.StartTime).Seconds()))
+			levelBonus := 100 * g.Level
+			g.emit(Event{Kind: EventLevelCompleted, Level: g.Level, Points: levelBonus + timeBonus})
+			for _, p := range g.active() {
+				g.addScore(p, levelBonus, ScoreLevelBonus)
+				if timeBonus > 0 {
+					g.addScore(p, timeBonus, ScoreTimeBonus)
+				}
+			}
+			g.recordLevelResult()
 			
 			// Set a timer to advance to next level after a brief pause
-			g.LevelCompleteTime = time.Now()
+			g.LevelCompleteTime = g.cfg.clock.Now()
 		} else {
 			// Check if enough time has passed (1 second) to advance to next level
-			if time.Since(g.LevelCompleteTime) >= 1*time.Second {
+			if g.since(g.LevelCompleteTime) >= 1*time.Second {
 				g.nextLevel()
 			}
 		}
@@ -187,95 +224,83 @@ func (g *Game) checkLevelComplete() {
 
 // nextLevel advances to the next level
 func (g *Game) nextLevel() {
-	if g.Level >= 10 {
+	if g.Level >= g.GetLevelCount() && !g.cfg.endless {
 		// Game completed!
+		g.State = Victory
+		g.settleWinner()
+		g.emit(Event{Kind: EventVictory, Level: g.Level, Score: g.Score, Player: g.Winner})
 		return
 	}
 	
 	g.Level++
 	g.AlertsCollected = 0
-	// Progressive difficulty but keep it reasonable
-	g.AlertsNeeded = 5 + (g.Level-1) // Level 1: 5, Level 2: 6, ..., Level 10: 14
-	g.StartTime = time.Now()
+	g.StartTime = g.cfg.clock.Now()
 	g.State = Playing
 	
-	// Reset positions and clear trail for new level
-	g.Commander = Position{X: g.Width / 2, Y: g.Height / 2}
-	g.Trail = make([]Position, 0) // Reset trail for new level
+	g.startLevel()
 	
-	// Clear alerts and obstacles
-	g.Alerts = make([]Position, 0)
-	g.Obstacles = make([]Position, 0)
+	g.emit(Event{Kind: EventLevelStarted, Level: g.Level})
+}
+
+// startLevel resets the board and lays out the current level from its
+// definition in the level pack
+func (g *Game) startLevel() {
+	g.buildEndlessLevel()
+	level := g.levelDef()
+	g.AlertsNeeded = g.alertsNeeded(level)
+	if level.Width > 0 && level.Height > 0 {
+		g.Width, g.Height = level.Width, level.Height
+	}
 	
-	// Setup new level
-	g.setupLevel()
+	// Speed modifiers and combos only last for the level they were gained on
+	g.ClearSpeedModifiers()
 	
-	// Safety check: Remove any obstacles at commander spawn position
-	commanderPos := g.Commander
-	for i := len(g.Obstacles) - 1; i >= 0; i-- {
-		if g.Obstacles[i].X == commanderPos.X && g.Obstacles[i].Y == commanderPos.Y {
-			// Remove obstacle that would collide with commander
-			g.Obstacles = append(g.Obstacles[:i], g.Obstacles[i+1:]...)
-		}
-	}
+	// Reset positions, combos and trails for new level
+	g.resetPlayers()
+	
+	// Clear alerts
+	g.Alerts = make([]Alert, 0)
+	g.resetGrid()
+	
+	// Setup new level, regenerating boards that fail validation
+	g.buildLayout()
 	
 	g.spawnAlerts()
 }
 
-// setupLevel configures obstacles and layout for the current level
-func (g *Game) setupLevel() {
-	switch g.Level {
-	case 3, 4: // Static barriers
-		g.addStaticBarriers()
-	case 5, 6: // Moving obstacles (simplified as static for now)
-		g.addStaticBarriers()
-		g.addRandomObstacles(2)
-	case 7, 8: // More complex layouts
-		g.addRandomObstacles(4)
-	case 9, 10: // Maximum difficulty
-		g.addMazeLayout()
-	}
-}
-
 // spawnAlerts spawns new alert bubbles
 func (g *Game) spawnAlerts() {
-	for len(g.Alerts) < 3 { // Keep 3 alerts on screen
-		for {
-			x := rand.Intn(g.Width)
-			y := rand.Intn(g.Height)
-			pos := Position{X: x, Y: y}
-			
-			// Don't spawn on commander, trail, or obstacles
-			if !g.isPositionOccupied(pos) {
-				g.Alerts = append(g.Alerts, pos)
-				break
-			}
+	for len(g.Alerts) < g.levelDef().AlertsOnScreen {
+		// Don't spawn on commander, trail, or obstacles
+		pos, ok := g.pickSpawnCell()
+		if !ok {
+			break
 		}
+		alert := g.newAlert(pos)
+		g.Alerts = append(g.Alerts, alert)
+		g.setCell(pos, CellAlert)
+		g.emit(Event{Kind: EventAlertSpawned, Position: pos, Severity: alert.Severity})
+	}
+	
+	// With nothing left to collect the level can never be cleared
+	if len(g.Alerts) == 0 && g.AlertsCollected < g.AlertsNeeded && g.State == Playing {
+		g.saturate()
 	}
 }
 
 // isPositionOccupied checks if a position is occupied
 func (g *Game) isPositionOccupied(pos Position) bool {
-	// Check commander
-	if g.Commander.X == pos.X && g.Commander.Y == pos.Y {
+	// Check commanders
+	if g.isCommander(pos) {
 		return true
 	}
 	
-	// Check trail
-	for _, segment := range g.Trail {
-		if segment.X == pos.X && segment.Y == pos.Y {
-			return true
-		}
-	}
-	
-	// Check obstacles
-	for _, obstacle := range g.Obstacles {
-		if obstacle.X == pos.X && obstacle.Y == pos.Y {
-			return true
-		}
+	// Check trail, obstacles and other alerts
+	if g.CellAt(pos) != CellEmpty {
+		return true
 	}
 	
-	return false
+	return g.hitsMovingObstacle(pos) || g.hitsArmedBreaker(pos)
 }
 
 // addStaticBarriers adds static barrier obstacles
@@ -283,46 +308,57 @@ func (g *Game) addStaticBarriers() {
 	// Add cross pattern with safe distance from center to avoid commander spawn
 	centerX, centerY := g.Width/2, g.Height/2
 	
+	// Barrier offset and edge margin grow with the board so larger levels
+	// keep the same proportions as the 20x20 layout
+	offset := g.scale(4)
+	margin := g.scale(2)
+	
 	// Create cross pattern with at least 3 cells gap from commander
 	// Horizontal barriers (top and bottom of screen)
-	for x := 2; x < g.Width-2; x++ {
+	for x := margin; x < g.Width-margin; x++ {
 		// Skip area around commander spawn (leave 3x3 safe zone)
 		if abs(x-centerX) > 2 {
 			// Top horizontal line
-			if centerY-4 >= 0 {
-				g.Obstacles = append(g.Obstacles, Position{X: x, Y: centerY - 4})
+			if centerY-offset >= 0 {
+				g.placeObstacle(Position{X: x, Y: centerY - offset})
 			}
 			// Bottom horizontal line  
-			if centerY+4 < g.Height {
-				g.Obstacles = append(g.Obstacles, Position{X: x, Y: centerY + 4})
+			if centerY+offset < g.Height {
+				g.placeObstacle(Position{X: x, Y: centerY + offset})
 			}
 		}
 	}
 	
 	// Vertical barriers (left and right sides)
-	for y := 2; y < g.Height-2; y++ {
+	for y := margin; y < g.Height-margin; y++ {
 		// Skip area around commander spawn (leave 3x3 safe zone)
 		if abs(y-centerY) > 2 {
 			// Left vertical line
-			if centerX-4 >= 0 {
-				g.Obstacles = append(g.Obstacles, Position{X: centerX - 4, Y: y})
+			if centerX-offset >= 0 {
+				g.placeObstacle(Position{X: centerX - offset, Y: y})
 			}
 			// Right vertical line
-			if centerX+4 < g.Width {
-				g.Obstacles = append(g.Obstacles, Position{X: centerX + 4, Y: y})
+			if centerX+offset < g.Width {
+				g.placeObstacle(Position{X: centerX + offset, Y: y})
 			}
 		}
 	}
 }
 
+// scale scales a generator dimension tuned for a 20x20 board to the current
+// board size
+func (g *Game) scale(n int) int {
+	return max(1, n*min(g.Width, g.Height)/baseBoardSize)
+}
+
 // addRandomObstacles adds random obstacle positions
 func (g *Game) addRandomObstacles(count int) {
 	centerX, centerY := g.Width/2, g.Height/2
 	
 	for i := 0; i < count; i++ {
 		for attempts := 0; attempts < 50; attempts++ {
-			x := rand.Intn(g.Width)
-			y := rand.Intn(g.Height)
+			x := g.rng.Intn(g.Width)
+			y := g.rng.Intn(g.Height)
 			pos := Position{X: x, Y: y}
 			
 			// Don't place obstacles too close to commander spawn (maintain 3x3 safe zone)
@@ -331,20 +367,21 @@ func (g *Game) addRandomObstacles(count int) {
 			}
 			
 			if !g.isPositionOccupied(pos) {
-				g.Obstacles = append(g.Obstacles, pos)
+				g.placeObstacle(pos)
 				break
 			}
 		}
 	}
 }
 
-// addMazeLayout creates a maze-like obstacle layout
-func (g *Game) addMazeLayout() {
+// addMazeLayout creates a maze-like obstacle layout with posts every
+// spacing cells
+func (g *Game) addMazeLayout(spacing int) {
 	// Simple maze pattern that avoids commander spawn area
 	centerX, centerY := g.Width/2, g.Height/2
 	
-	for x := 2; x < g.Width-2; x += 4 {
-		for y := 2; y < g.Height-2; y += 4 {
+	for x := 2; x < g.Width-2; x += spacing {
+		for y := 2; y < g.Height-2; y += spacing {
 			pos := Position{X: x, Y: y}
 			
 			// Skip positions too close to commander spawn (maintain 3x3 safe zone)
@@ -353,11 +390,11 @@ func (g *Game) addMazeLayout() {
 			}
 			
 			if !g.isPositionOccupied(pos) {
-				g.Obstacles = append(g.Obstacles, pos)
+				g.placeObstacle(pos)
 				
 				// Add connecting obstacle
 				var nextPos Position
-				if rand.Intn(2) == 0 {
+				if g.rng.Intn(2) == 0 {
 					nextPos = Position{X: x + 1, Y: y}
 				} else {
 					nextPos = Position{X: x, Y: y + 1}
@@ -367,48 +404,68 @@ func (g *Game) addMazeLayout() {
 				if nextPos.X < g.Width && nextPos.Y < g.Height && 
 				   !g.isPositionOccupied(nextPos) &&
 				   !(abs(nextPos.X-centerX) <= 2 && abs(nextPos.Y-centerY) <= 2) {
-					g.Obstacles = append(g.Obstacles, nextPos)
+					g.placeObstacle(nextPos)
 				}
 			}
 		}
 	}
 }
 
-// Public getters
-func (g *Game) GetCommander() Position { return g.Commander }
-func (g *Game) GetTrail() []Position { return g.Trail }
-func (g *Game) GetAlerts() []Position { return g.Alerts }
+// Public getters; the commander, trail and score are the first player's
+func (g *Game) GetCommander() Position { return g.Players[0].Commander }
+func (g *Game) GetTrail() []Position { return g.Players[0].Trail }
+func (g *Game) GetAlerts() []Alert { return g.Alerts }
 func (g *Game) GetObstacles() []Position { return g.Obstacles }
-func (g *Game) GetScore() int { return g.Score }
+func (g *Game) GetScore() int { return g.Players[0].Score }
 func (g *Game) GetLevel() int { return g.Level }
 func (g *Game) GetAlertsCollected() int { return g.AlertsCollected }
 func (g *Game) GetAlertsNeeded() int { return g.AlertsNeeded }
 func (g *Game) GetState() GameState { return g.State }
 func (g *Game) GetWidth() int { return g.Width }
 func (g *Game) GetHeight() int { return g.Height }
+func (g *Game) GetSeed() int64 { return g.cfg.seed }
 func (g *Game) IsRunning() bool { return g.State == Playing }
 
 // Control methods
 func (g *Game) SetDirection(dir Direction) {
-	// Prevent immediate reversal
-	opposite := map[Direction]Direction{
-		Up: Down, Down: Up, Left: Right, Right: Left,
-	}
-	if g.Direction != opposite[dir] {
-		g.Direction = dir
+	g.SetPlayerDirection(0, dir)
+}
+
+// SetPlayerDirection turns the given player's commander. Unknown players
+// and players already out are ignored.
+func (g *Game) SetPlayerDirection(player int, dir Direction) {
+	if player < 0 || player >= len(g.Players) || g.Players[player].Out {
+		return
 	}
+	// Queue the turn; it applies on a coming tick against the direction
+	// actually moved, which prevents reversing into the trail
+	g.queueTurn(g.Players[player], dir)
 }
 
 func (g *Game) Pause() {
 	if g.State == Playing {
 		g.State = Paused
+		g.emit(Event{Kind: EventPaused})
 	} else if g.State == Paused {
 		g.State = Playing
+		g.emit(Event{Kind: EventResumed})
 	}
 }
 
+// Restart starts a fresh game with the same options. The new seed is drawn
+// from the current generator, so a restarted session stays reproducible.
 func (g *Game) Restart() {
-	*g = *New(g.Width, g.Height)
+	cfg := g.cfg
+	cfg.seed = g.rng.Int63()
+	bus := g.bus
+	*g = *newGame(g.Width, g.Height, cfg)
+	g.bus = bus
+	g.emit(Event{Kind: EventLevelStarted, Level: g.Level})
+}
+
+// since returns the time elapsed on the game's clock since t
+func (g *Game) since(t time.Time) time.Duration {
+	return g.cfg.clock.Now().Sub(t)
 }
 
 // Utility functions
@@ -0,0 +1,120 @@
+package game
+
+// Cell is what occupies a board cell in the occupancy grid. The commander,
+// moving obstacles and breaker walls are tracked separately.
+type Cell uint8
+
+const (
+	CellEmpty Cell = iota
+	CellTrail
+	CellObstacle
+	CellAlert
+)
+
+// CellAt returns what occupies p in constant time. Cells off the board are
+// reported as empty; check the board bounds separately.
+func (g *Game) CellAt(p Position) Cell {
+	if !inBounds(p, g.Width, g.Height) {
+		return CellEmpty
+	}
+	return g.grid[p.Y*g.Width+p.X]
+}
+
+// setCell records what occupies p and keeps the free-cell set in step
+func (g *Game) setCell(p Position, c Cell) {
+	if !inBounds(p, g.Width, g.Height) {
+		return
+	}
+	i := p.Y*g.Width + p.X
+	switch {
+	case g.grid[i] == CellEmpty && c != CellEmpty:
+		g.free.add(i, -1)
+	case g.grid[i] != CellEmpty && c == CellEmpty:
+		g.free.add(i, 1)
+	}
+	g.grid[i] = c
+}
+
+// resetGrid sizes the occupancy grid for the board and clears it
+func (g *Game) resetGrid() {
+	if cells := g.Width * g.Height; cap(g.grid) >= cells {
+		g.grid = g.grid[:cells]
+		clear(g.grid)
+	} else {
+		g.grid = make([]Cell, cells)
+	}
+	g.free.reset(len(g.grid))
+}
+
+// freeCells is the set of empty grid cells, kept as a Fenwick tree over the
+// grid so the set can be counted and its k-th cell found in O(log n). The
+// order only depends on the board, so a restored game draws the same cells.
+type freeCells struct {
+	tree []int
+}
+
+// reset marks all n cells free
+func (f *freeCells) reset(n int) {
+	if cap(f.tree) >= n+1 {
+		f.tree = f.tree[:n+1]
+	} else {
+		f.tree = make([]int, n+1)
+	}
+	for i := 1; i <= n; i++ {
+		f.tree[i] = i & -i
+	}
+}
+
+// add changes the free count of cell i by delta
+func (f *freeCells) add(i, delta int) {
+	for i++; i < len(f.tree); i += i & -i {
+		f.tree[i] += delta
+	}
+}
+
+// count returns the number of free cells
+func (f *freeCells) count() int {
+	n, total := len(f.tree)-1, 0
+	for ; n > 0; n -= n & -n {
+		total += f.tree[n]
+	}
+	return total
+}
+
+// nth returns the grid index of the k-th free cell, counting from 0
+func (f *freeCells) nth(k int) int {
+	pos, step := 0, 1
+	for step*2 < len(f.tree) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if next := pos + step; next < len(f.tree) && f.tree[next] <= k {
+			pos = next
+			k -= f.tree[next]
+		}
+	}
+	return pos
+}
+
+// rebuildGrid fills the occupancy grid from the obstacle, trails and alert
+// slices, for use after they were changed wholesale
+func (g *Game) rebuildGrid() {
+	g.resetGrid()
+	for _, p := range g.Obstacles {
+		g.setCell(p, CellObstacle)
+	}
+	for _, player := range g.Players {
+		for _, p := range player.Trail {
+			g.setCell(p, CellTrail)
+		}
+	}
+	for _, alert := range g.Alerts {
+		g.setCell(alert.Position, CellAlert)
+	}
+}
+
+// placeObstacle adds an obstacle at pos
+func (g *Game) placeObstacle(pos Position) {
+	g.Obstacles = append(g.Obstacles, pos)
+	g.setCell(pos, CellObstacle)
+}
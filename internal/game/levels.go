@@ -0,0 +1,293 @@
+package game
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//go:embed levels/default.json
+var defaultPackJSON []byte
+
+// defaultPack is the built-in level pack, parsed once at startup
+var defaultPack = mustLoadLevelPack(defaultPackJSON)
+
+// LevelPack is an ordered set of level definitions
+type LevelPack struct {
+	Name   string  `json:"name"`
+	Levels []Level `json:"levels"`
+}
+
+// Level defines the board, pacing and obstacle layout of one level
+type Level struct {
+	Name             string     `json:"name"`
+	Width            int        `json:"width,omitempty"`  // Board width; 0 keeps the current board
+	Height           int        `json:"height,omitempty"` // Board height; 0 keeps the current board
+	TickIntervalMS   int        `json:"tick_interval_ms"` // Time between commander moves
+	AlertsNeeded     int        `json:"alerts_needed"`
+	AlertsOnScreen   int        `json:"alerts_on_screen"`
+	ComboWindowTicks int        `json:"combo_window_ticks,omitempty"` // Ticks a combo lasts without a pickup; 0 uses the default
+	SeverityWeights  [3]int     `json:"severity_weights"`             // Spawn weights of SEV1, SEV2 and SEV3 alerts; all zero uses the default
+	AckTicks         [3]int     `json:"ack_ticks"`                    // Ticks SEV1, SEV2 and SEV3 alerts wait before escalating; zero uses the default
+	Outage           string     `json:"outage,omitempty"`             // What an unhandled SEV1 alert becomes: "penalty" (default) or "obstacle"
+	Edges            string     `json:"edges,omitempty"`              // Board edges: "solid" (default), "wrap" or "bounce"
+	Spawn            SpawnRules `json:"spawn,omitempty"`              // Where alerts spawn; uniform by default
+	Layout           Layout     `json:"layout"`
+}
+
+// Layout declares the obstacles of a level. All forms may be combined; they
+// are applied in the order cells, map, generators, moving, breakers.
+type Layout struct {
+	Cells      [][2]int      `json:"cells,omitempty"`      // Explicit [x, y] obstacle cells
+	Map        []string      `json:"map,omitempty"`        // ASCII rows, '#' marks an obstacle
+	Generators []Generator   `json:"generators,omitempty"` // Procedural obstacle generators
+	Moving     []MovingSpec  `json:"moving,omitempty"`     // Patrolling obstacles
+	Breakers   []BreakerSpec `json:"breakers,omitempty"`   // Circuit breaker walls
+}
+
+// Generator names a procedural obstacle generator and its parameters:
+//
+//	static_barriers: cross-shaped barriers around the spawn point
+//	random:          "count" obstacles at random free cells
+//	maze:            maze posts every "spacing" cells (default 4)
+//	patrol:          "count" straight patrols of "length" cells (default 6)
+//	                 moving every "every" ticks (default 3)
+//	breakers:        "count" circuit breaker walls of "length" cells
+//	                 (default 4) with "open", "warning" and "closed" phase
+//	                 lengths in ticks (defaults 20, 4, 12)
+//
+// Counts and lengths are tuned for a 20x20 board and scale with larger ones.
+type Generator struct {
+	Type   string         `json:"type"`
+	Params map[string]int `json:"params,omitempty"`
+}
+
+// param returns the named parameter or def when it is not set
+func (gen Generator) param(name string, def int) int {
+	if v, ok := gen.Params[name]; ok {
+		return v
+	}
+	return def
+}
+
+// DefaultLevelPack returns the built-in ten-level pack
+func DefaultLevelPack() *LevelPack {
+	return defaultPack
+}
+
+// LoadLevelPack parses and validates a JSON level pack
+func LoadLevelPack(data []byte) (*LevelPack, error) {
+	var pack LevelPack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("game: invalid level pack: %w", err)
+	}
+	if err := pack.Validate(); err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
+// mustLoadLevelPack parses a level pack that is known to be valid
+func mustLoadLevelPack(data []byte) *LevelPack {
+	pack, err := LoadLevelPack(data)
+	if err != nil {
+		panic(err)
+	}
+	return pack
+}
+
+// hash returns a short fingerprint of the pack's contents, so a save can
+// tell whether it is loaded with the pack it was made on
+func (p *LevelPack) hash() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Validate checks that every level in the pack can be played
+func (p *LevelPack) Validate() error {
+	if len(p.Levels) == 0 {
+		return errors.New("game: level pack has no levels")
+	}
+	for i, level := range p.Levels {
+		if err := level.validate(); err != nil {
+			return fmt.Errorf("game: level %d (%s): %w", i+1, level.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks a single level definition
+func (l Level) validate() error {
+	if (l.Width == 0) != (l.Height == 0) {
+		return errors.New("width and height must be set together")
+	}
+	if l.Width != 0 && (l.Width < 5 || l.Height < 5) {
+		return errors.New("board must be at least 5x5")
+	}
+	if l.TickIntervalMS <= 0 {
+		return errors.New("tick_interval_ms must be positive")
+	}
+	if l.AlertsNeeded <= 0 || l.AlertsOnScreen <= 0 {
+		return errors.New("alerts_needed and alerts_on_screen must be positive")
+	}
+	if l.ComboWindowTicks < 0 {
+		return errors.New("combo_window_ticks must not be negative")
+	}
+	for _, w := range l.SeverityWeights {
+		if w < 0 {
+			return errors.New("severity_weights must not be negative")
+		}
+	}
+	for _, t := range l.AckTicks {
+		if t < 0 {
+			return errors.New("ack_ticks must not be negative")
+		}
+	}
+	if _, err := ParseEdgePolicy(l.Edges); err != nil {
+		return err
+	}
+	if err := l.Spawn.validate(); err != nil {
+		return err
+	}
+	if l.Outage != "" && l.Outage != OutagePenalty && l.Outage != OutageObstacle {
+		return fmt.Errorf("unknown outage policy %q", l.Outage)
+	}
+	for _, cell := range l.Layout.Cells {
+		if cell[0] < 0 || cell[1] < 0 {
+			return fmt.Errorf("cell %v is outside the board", cell)
+		}
+	}
+	for _, row := range l.Layout.Map {
+		if strings.Trim(row, "#. ") != "" {
+			return fmt.Errorf("map row %q may only contain '#', '.' and ' '", row)
+		}
+	}
+	for _, gen := range l.Layout.Generators {
+		switch gen.Type {
+		case "static_barriers", "random", "maze", "patrol", "breakers":
+		default:
+			return fmt.Errorf("unknown generator %q", gen.Type)
+		}
+	}
+	for _, spec := range l.Layout.Moving {
+		path, mode, err := spec.parse()
+		if err != nil {
+			return err
+		}
+		if !straightPath(path, mode) {
+			return errors.New("moving obstacle waypoints must share a row or column")
+		}
+	}
+	for _, spec := range l.Layout.Breakers {
+		if _, err := spec.parse(); err != nil {
+			return err
+		}
+	}
+	return l.validateExplicit()
+}
+
+// levelDef returns the definition of the current level
+func (g *Game) levelDef() *Level {
+	if g.Level > len(g.cfg.pack.Levels) {
+		return g.endlessDef
+	}
+	return &g.cfg.pack.Levels[g.Level-1]
+}
+
+// buildEndlessLevel builds the definition of the current level when it lies
+// past the end of the pack. It is built once per level since levelDef is
+// called on every tick.
+func (g *Game) buildEndlessLevel() {
+	g.endlessDef = nil
+	if g.Level > len(g.cfg.pack.Levels) {
+		level := g.cfg.pack.endlessLevel(g.Level)
+		g.endlessDef = &level
+	}
+}
+
+// applyLayout adds the current level's obstacles to the board
+func (g *Game) applyLayout() {
+	layout := g.levelDef().Layout
+
+	for _, cell := range layout.Cells {
+		g.addObstacle(Position{X: cell[0], Y: cell[1]})
+	}
+	for y, row := range layout.Map {
+		for x, c := range row {
+			if c == '#' {
+				g.addObstacle(Position{X: x, Y: y})
+			}
+		}
+	}
+	for _, gen := range layout.Generators {
+		switch gen.Type {
+		case "static_barriers":
+			g.addStaticBarriers()
+		case "random":
+			g.addRandomObstacles(gen.param("count", 0) * g.Width * g.Height / (baseBoardSize * baseBoardSize))
+		case "maze":
+			g.addMazeLayout(max(2, gen.param("spacing", 4)))
+		case "patrol":
+			g.addPatrols(gen.param("count", 1), max(2, g.scale(gen.param("length", 6))), gen.param("every", 3))
+		case "breakers":
+			g.addBreakers(gen.param("count", 1), max(1, g.scale(gen.param("length", 4))),
+				max(1, gen.param("open", 20)), max(1, gen.param("warning", 4)), max(1, gen.param("closed", 12)))
+		}
+	}
+	for _, spec := range layout.Moving {
+		if path, mode, err := spec.parse(); err == nil {
+			every := spec.Every
+			if every == 0 {
+				every = 3
+			}
+			g.addMovingObstacle(newMovingObstacle(path, mode, every))
+		}
+	}
+	for _, spec := range layout.Breakers {
+		if cells, err := spec.parse(); err == nil {
+			g.addBreakerWall(newBreakerWall(cells, spec.Open, spec.Warning, spec.Closed, spec.Offset))
+		}
+	}
+}
+
+// parse converts the spec's waypoints and mode
+func (spec MovingSpec) parse() ([]Position, PatrolMode, error) {
+	if len(spec.Path) == 0 {
+		return nil, 0, errors.New("moving obstacle needs at least one waypoint")
+	}
+	path := make([]Position, len(spec.Path))
+	for i, p := range spec.Path {
+		if p[0] < 0 || p[1] < 0 {
+			return nil, 0, fmt.Errorf("waypoint %v is outside the board", p)
+		}
+		path[i] = Position{X: p[0], Y: p[1]}
+	}
+	switch spec.Mode {
+	case "", "loop":
+		return path, PatrolLoop, nil
+	case "bounce":
+		return path, PatrolBounce, nil
+	}
+	return nil, 0, fmt.Errorf("unknown patrol mode %q", spec.Mode)
+}
+
+// addObstacle places an explicit obstacle if it lies on the board
+func (g *Game) addObstacle(pos Position) {
+	if pos.X < g.Width && pos.Y < g.Height && !g.isPositionOccupied(pos) {
+		g.placeObstacle(pos)
+	}
+}
+
+// CurrentLevel returns the definition of the level being played
+func (g *Game) CurrentLevel() Level { return *g.levelDef() }
+
+// GetLevelCount returns the number of levels in the game's level pack
+func (g *Game) GetLevelCount() int { return len(g.cfg.pack.Levels) }
@@ -0,0 +1,77 @@
+package game
+
+import "fmt"
+
+// GameMode selects how the commander's trail behaves
+type GameMode int
+
+const (
+	LightCycle GameMode = iota // The trail keeps every cell visited this level
+	Classic                    // Snake rules: the trail only grows by collecting alerts
+)
+
+// Classic mode trail length: the commander starts with a short tail that
+// grows with every alert collected on the level
+const (
+	classicBaseTrail     = 2
+	classicTrailPerAlert = 2
+)
+
+// modeRules adjusts scoring and pacing for a mode. A Classic board never
+// fills up with trail, so levels need more alerts, run a little faster and
+// pay out less per alert.
+type modeRules struct {
+	alertsPercent int // Scales each level's alerts_needed
+	tickPercent   int // Scales each level's tick interval
+	pointsPercent int // Scales the points earned per alert
+}
+
+// rules returns the scoring and pacing rules for the mode
+func (m GameMode) rules() modeRules {
+	if m == Classic {
+		return modeRules{alertsPercent: 150, tickPercent: 90, pointsPercent: 50}
+	}
+	return modeRules{alertsPercent: 100, tickPercent: 100, pointsPercent: 100}
+}
+
+// String returns the mode's name as used in URLs and saves
+func (m GameMode) String() string {
+	if m == Classic {
+		return "classic"
+	}
+	return "lightcycle"
+}
+
+// ParseGameMode parses a mode name as returned by GameMode.String
+func ParseGameMode(name string) (GameMode, error) {
+	switch name {
+	case "lightcycle":
+		return LightCycle, nil
+	case "classic":
+		return Classic, nil
+	}
+	return LightCycle, fmt.Errorf("game: unknown game mode %q", name)
+}
+
+// GetMode returns the game's mode
+func (g *Game) GetMode() GameMode { return g.cfg.mode }
+
+// trimTrail drops the player's oldest trail cells beyond the Classic mode
+// length, which grows with the alerts that player collected
+func (g *Game) trimTrail(p *Player) {
+	if g.cfg.mode != Classic {
+		return
+	}
+	length := classicBaseTrail + classicTrailPerAlert*p.Collected
+	if len(p.Trail) > length {
+		for _, cell := range p.Trail[:len(p.Trail)-length] {
+			g.setCell(cell, CellEmpty)
+		}
+		p.Trail = append(p.Trail[:0], p.Trail[len(p.Trail)-length:]...)
+	}
+}
+
+// alertsNeeded returns how many alerts a level takes to clear in this mode
+func (g *Game) alertsNeeded(level *Level) int {
+	return (level.AlertsNeeded*g.cfg.mode.rules().alertsPercent + 99) / 100
+}
@@ -0,0 +1,260 @@
+package game
+
+// PatrolMode selects how a moving obstacle walks its path
+type PatrolMode int
+
+const (
+	PatrolLoop   PatrolMode = iota // Return from the last waypoint to the first
+	PatrolBounce                   // Walk the waypoints back and forth
+)
+
+// MovingObstacle is an obstacle that patrols a path of waypoints, moving one
+// cell every StepEvery ticks
+type MovingObstacle struct {
+	Position  Position   `json:"position"`
+	Path      []Position `json:"path"` // Waypoints; the first is the starting cell
+	Mode      PatrolMode `json:"mode"`
+	StepEvery int        `json:"step_every"` // Ticks between moves
+	Target    int        `json:"target"`     // Index of the waypoint being walked to
+	Reverse   bool       `json:"reverse"`    // Walking the path backwards
+	Cooldown  int        `json:"cooldown"`   // Ticks left until the next move
+}
+
+// MovingSpec declares a moving obstacle in a level layout
+type MovingSpec struct {
+	Path  [][2]int `json:"path"`            // Waypoints as [x, y] pairs
+	Mode  string   `json:"mode,omitempty"`  // "loop" (default) or "bounce"
+	Every int      `json:"every,omitempty"` // Ticks between moves, default 3
+}
+
+// newMovingObstacle creates an obstacle at the first waypoint of path
+func newMovingObstacle(path []Position, mode PatrolMode, every int) MovingObstacle {
+	return MovingObstacle{
+		Position:  path[0],
+		Path:      path,
+		Mode:      mode,
+		StepEvery: max(1, every),
+		Target:    1 % len(path),
+		Cooldown:  max(1, every),
+	}
+}
+
+// cells returns every cell the obstacle visits while patrolling
+func (m *MovingObstacle) cells() []Position {
+	var cells []Position
+	for i := range m.Path {
+		next := i + 1
+		if next == len(m.Path) {
+			if m.Mode != PatrolLoop {
+				break
+			}
+			next = 0
+		}
+		for p := m.Path[i]; ; p = stepToward(p, m.Path[next]) {
+			cells = append(cells, p)
+			if p == m.Path[next] {
+				break
+			}
+		}
+	}
+	if len(cells) == 0 {
+		cells = append(cells, m.Path[0])
+	}
+	return cells
+}
+
+// advanceTarget picks the next waypoint once the current one is reached
+func (m *MovingObstacle) advanceTarget() {
+	if len(m.Path) < 2 {
+		return
+	}
+	switch m.Mode {
+	case PatrolBounce:
+		if m.Reverse && m.Target == 0 || !m.Reverse && m.Target == len(m.Path)-1 {
+			m.Reverse = !m.Reverse
+		}
+		if m.Reverse {
+			m.Target--
+		} else {
+			m.Target++
+		}
+	default:
+		if m.Reverse {
+			m.Target = (m.Target + len(m.Path) - 1) % len(m.Path)
+		} else {
+			m.Target = (m.Target + 1) % len(m.Path)
+		}
+	}
+}
+
+// turnAround makes the obstacle head back the way it came
+func (m *MovingObstacle) turnAround() {
+	if len(m.Path) < 2 {
+		return
+	}
+	m.Reverse = !m.Reverse
+	m.advanceTarget()
+	if m.Mode == PatrolBounce && m.Path[m.Target] == m.Position {
+		m.advanceTarget()
+	}
+}
+
+// stepToward moves one cell from p toward target, horizontally first
+func stepToward(p, target Position) Position {
+	switch {
+	case p.X < target.X:
+		p.X++
+	case p.X > target.X:
+		p.X--
+	case p.Y < target.Y:
+		p.Y++
+	case p.Y > target.Y:
+		p.Y--
+	}
+	return p
+}
+
+// moveObstacles advances every moving obstacle due to move this tick. An
+// obstacle that would run into the trail, an alert or another obstacle
+// turns around; one that runs into a commander knocks it out.
+func (g *Game) moveObstacles(result *StepResult) {
+	for i := range g.MovingObstacles {
+		m := &g.MovingObstacles[i]
+		if len(m.Path) < 2 {
+			continue
+		}
+		if m.Cooldown--; m.Cooldown > 0 {
+			continue
+		}
+		m.Cooldown = m.StepEvery
+
+		next := stepToward(m.Position, m.Path[m.Target])
+		if hit := g.commanderAt(next); hit != nil {
+			m.Position = next
+			g.collide(hit, CollisionObstacle, &result.Players[hit.ID])
+			continue
+		}
+		if g.blocksPatrol(next, i) {
+			m.turnAround()
+			continue
+		}
+
+		m.Position = next
+		if m.Position == m.Path[m.Target] {
+			m.advanceTarget()
+		}
+	}
+}
+
+// blocksPatrol reports whether pos is taken by a trail, an alert, a static
+// obstacle, a closed breaker wall or a moving obstacle other than the one
+// at index self. Alerts block so a patrol never hides one from the player.
+func (g *Game) blocksPatrol(pos Position, self int) bool {
+	if g.hitsClosedBreaker(pos) {
+		return true
+	}
+	if c := g.CellAt(pos); c == CellTrail || c == CellObstacle || c == CellAlert {
+		return true
+	}
+	for i, m := range g.MovingObstacles {
+		if i != self && m.Position == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// hitsMovingObstacle reports whether pos holds a moving obstacle
+func (g *Game) hitsMovingObstacle(pos Position) bool {
+	for _, m := range g.MovingObstacles {
+		if m.Position == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// inSpawnZone reports whether pos is inside the safe zone kept clear of
+// moving obstacles and breaker walls: the middle of the board, where a lone
+// commander spawns, and the cells around every commander's spawn point
+func (g *Game) inSpawnZone(pos Position) bool {
+	if abs(pos.X-g.Width/2) <= 2 && abs(pos.Y-g.Height/2) <= 2 {
+		return true
+	}
+	for _, p := range g.active() {
+		for _, cell := range spawnGuard(p) {
+			if cell == pos {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// spawnGuard returns the cells a commander needs clear when it spawns: its
+// own, its neighbours, which include the cell of its first move, and the
+// cell after that
+func spawnGuard(p *Player) [6]Position {
+	around := neighbours(p.Commander)
+	ahead := p.Commander.step(p.Direction).step(p.Direction)
+	return [6]Position{p.Commander, around[0], around[1], around[2], around[3], ahead}
+}
+
+// straightPath reports whether consecutive waypoints share a row or column,
+// so a patrol walks the same cells in both directions
+func straightPath(path []Position, mode PatrolMode) bool {
+	for i := range path {
+		next := i + 1
+		if next == len(path) {
+			if mode != PatrolLoop {
+				break
+			}
+			next = 0
+		}
+		if path[i].X != path[next].X && path[i].Y != path[next].Y {
+			return false
+		}
+	}
+	return true
+}
+
+// addMovingObstacle adds a patrol if its whole route is made of straight
+// lines that stay on the board, outside the spawn safe zone and off static
+// obstacles
+func (g *Game) addMovingObstacle(m MovingObstacle) bool {
+	if len(m.Path) == 0 || !straightPath(m.Path, m.Mode) {
+		return false
+	}
+	for _, p := range m.cells() {
+		if p.X < 0 || p.X >= g.Width || p.Y < 0 || p.Y >= g.Height || g.inSpawnZone(p) || g.CellAt(p) == CellObstacle {
+			return false
+		}
+	}
+	if g.hitsMovingObstacle(m.Position) {
+		return false
+	}
+	g.MovingObstacles = append(g.MovingObstacles, m)
+	return true
+}
+
+// addPatrols adds count randomly placed straight patrols of the given length
+// that bounce between their ends, moving every few ticks
+func (g *Game) addPatrols(count, length, every int) {
+	for i := 0; i < count; i++ {
+		for attempts := 0; attempts < 50; attempts++ {
+			start := Position{X: g.rng.Intn(g.Width), Y: g.rng.Intn(g.Height)}
+			end := start
+			if g.rng.Intn(2) == 0 {
+				end.X += length - 1
+			} else {
+				end.Y += length - 1
+			}
+			if g.addMovingObstacle(newMovingObstacle([]Position{start, end}, PatrolBounce, every)) {
+				break
+			}
+		}
+	}
+}
+
+// GetMovingObstacles returns the level's moving obstacles
+func (g *Game) GetMovingObstacles() []MovingObstacle { return g.MovingObstacles }
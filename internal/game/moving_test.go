@@ -0,0 +1,108 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// openPack returns a one-level pack with an empty board of the given size
+// and alerts that take far longer to escalate than any test runs
+func openPack(width, height int) *LevelPack {
+	return &LevelPack{Name: "Open", Levels: []Level{{
+		Name:           "Open Floor",
+		Width:          width,
+		Height:         height,
+		TickIntervalMS: 100,
+		AlertsNeeded:   100,
+		AlertsOnScreen: 1,
+		AckTicks:       [3]int{1000, 1000, 1000},
+	}}}
+}
+
+// placeAlerts replaces the alerts on the board with SEV3 alerts at cells
+func placeAlerts(g *Game, cells ...Position) {
+	for _, alert := range g.Alerts {
+		g.setCell(alert.Position, CellEmpty)
+	}
+	g.Alerts = make([]Alert, 0, len(cells))
+	for _, pos := range cells {
+		g.Alerts = append(g.Alerts, Alert{Position: pos, Severity: Sev3, TicksLeft: 1000})
+		g.setCell(pos, CellAlert)
+	}
+}
+
+// patrol returns a patrol standing at pos on its way to the second waypoint
+// of path, moving every tick when every is 1
+func patrol(pos Position, path []Position, every int) MovingObstacle {
+	m := newMovingObstacle(path, PatrolBounce, every)
+	m.Position = pos
+	return m
+}
+
+func TestPatrolCollisions(t *testing.T) {
+	// The commander spawns at (10, 10) heading right
+	tests := []struct {
+		name      string
+		patrol    MovingObstacle
+		alerts    []Position
+		ticks     int
+		collision CollisionKind
+		at        Position // Where the patrol ends up
+	}{
+		{
+			name:      "patrol runs into the commander",
+			patrol:    patrol(Position{X: 11, Y: 8}, []Position{{X: 11, Y: 6}, {X: 11, Y: 14}}, 1),
+			ticks:     2,
+			collision: CollisionObstacle,
+			at:        Position{X: 11, Y: 10},
+		},
+		{
+			name:      "commander runs into the patrol",
+			patrol:    patrol(Position{X: 13, Y: 10}, []Position{{X: 13, Y: 10}, {X: 13, Y: 14}}, 100),
+			ticks:     3,
+			collision: CollisionObstacle,
+			at:        Position{X: 13, Y: 10},
+		},
+		{
+			name:   "patrol turns at the trail",
+			patrol: patrol(Position{X: 10, Y: 7}, []Position{{X: 10, Y: 5}, {X: 10, Y: 14}}, 1),
+			ticks:  4,
+			at:     Position{X: 10, Y: 8},
+		},
+		{
+			name:   "patrol turns at an alert",
+			patrol: patrol(Position{X: 4, Y: 4}, []Position{{X: 4, Y: 2}, {X: 4, Y: 8}}, 1),
+			alerts: []Position{{X: 4, Y: 6}},
+			ticks:  3,
+			at:     Position{X: 4, Y: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(20, 20, WithSeed(1), WithClock(NewManualClock(time.Unix(0, 0))), WithLevelPack(openPack(20, 20)))
+			placeAlerts(g, append(tt.alerts, Position{X: 18, Y: 18})...)
+			g.MovingObstacles = []MovingObstacle{tt.patrol}
+
+			var collision CollisionKind
+			for i := 0; i < tt.ticks && collision == CollisionNone; i++ {
+				result := g.Step(nil)
+				collision = result.Collision
+				m := g.MovingObstacles[0]
+				if c := g.CellAt(m.Position); c == CellTrail || c == CellAlert {
+					t.Fatalf("tick %d: patrol moved onto a cell holding %v", result.Tick, c)
+				}
+			}
+			if collision != tt.collision {
+				t.Errorf("collision %v, want %v", collision, tt.collision)
+			}
+			if got := g.MovingObstacles[0].Position; got != tt.at {
+				t.Errorf("patrol at %v, want %v", got, tt.at)
+			}
+			for _, pos := range tt.alerts {
+				if g.CellAt(pos) != CellAlert {
+					t.Errorf("alert at %v is gone", pos)
+				}
+			}
+		})
+	}
+}
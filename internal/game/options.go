@@ -0,0 +1,108 @@
+package game
+
+import "time"
+
+// Option configures a Game created by New
+type Option func(*config)
+
+// config holds the settings collected from Options
+type config struct {
+	seed     int64
+	hasSeed  bool
+	clock    Clock
+	pack     *LevelPack
+	endless  bool
+	mode     GameMode
+	edges    EdgePolicy
+	hasEdges bool
+	players  int
+	win      WinCondition
+	packHash string // Fingerprint of pack, worked out on first use
+}
+
+// WithSeed makes the game deterministic: the same seed and the same inputs
+// always produce the same board, spawns and score
+func WithSeed(seed int64) Option {
+	return func(c *config) {
+		c.seed = seed
+		c.hasSeed = true
+	}
+}
+
+// WithClock sets the clock used for level timing. Games use RealClock by
+// default; pass a ManualClock to control time in tests and simulations.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithLevelPack plays a custom level pack instead of the built-in levels.
+// The pack should come from LoadLevelPack or have passed Validate.
+func WithLevelPack(pack *LevelPack) Option {
+	return func(c *config) {
+		c.pack = pack
+	}
+}
+
+// WithEndless keeps the game going after the last level with procedurally
+// scaled levels instead of ending in Victory
+func WithEndless() Option {
+	return func(c *config) {
+		c.endless = true
+	}
+}
+
+// WithMode selects the game mode; games default to LightCycle
+func WithMode(mode GameMode) Option {
+	return func(c *config) {
+		c.mode = mode
+	}
+}
+
+// WithEdges sets the board edge policy for every level, overriding the
+// edges each level declares
+func WithEdges(edges EdgePolicy) Option {
+	return func(c *config) {
+		c.edges = edges
+		c.hasEdges = true
+	}
+}
+
+// WithPlayers puts n commanders on the board, from 1 up to MaxPlayers.
+// Games default to a single commander.
+func WithPlayers(n int) Option {
+	return func(c *config) {
+		c.players = max(1, min(n, MaxPlayers))
+	}
+}
+
+// WithWinCondition sets how a match between several commanders is decided;
+// matches default to LastStanding
+func WithWinCondition(win WinCondition) Option {
+	return func(c *config) {
+		c.win = win
+	}
+}
+
+// newConfig applies the options on top of the defaults
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if !c.hasSeed {
+		c.seed = time.Now().UnixNano()
+		c.hasSeed = true
+	}
+	if c.clock == nil {
+		c.clock = RealClock{}
+	}
+	if c.players == 0 {
+		c.players = 1
+	}
+	if c.pack == nil {
+		c.pack = DefaultLevelPack()
+	}
+	return c
+}
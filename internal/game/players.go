@@ -0,0 +1,207 @@
+package game
+
+import "fmt"
+
+// MaxPlayers is the most commanders one board holds
+const MaxPlayers = 4
+
+// playerColors are the default colors of players 1 to 4
+var playerColors = [MaxPlayers]string{"#4a90d9", "#e8743b", "#9b59b6", "#19a979"}
+
+// Player is one commander on the board with its own trail, controls and score
+type Player struct {
+	ID             int         `json:"id"` // Index in Game.Players, used by commands and events
+	Name           string      `json:"name"`
+	Color          string      `json:"color"`
+	Commander      Position    `json:"commander"`
+	Trail          []Position  `json:"trail"`
+	Direction      Direction   `json:"direction"`
+	LastMoved      Direction   `json:"last_moved"`      // Direction of the last move made
+	Turns          []Direction `json:"turns,omitempty"` // Turns queued for coming ticks
+	Score          int         `json:"score"`
+	Collected      int         `json:"collected,omitempty"`        // Alerts collected on the current level
+	Combo          int         `json:"combo,omitempty"`            // Alerts collected in a row without a near miss
+	ComboTicksLeft int         `json:"combo_ticks_left,omitempty"` // Ticks left before the combo decays
+	Out            bool        `json:"out,omitempty"`              // Eliminated for the rest of the match
+}
+
+// WinCondition decides who wins a match between several commanders
+type WinCondition int
+
+const (
+	LastStanding WinCondition = iota // The match ends when one commander is left, who wins
+	HighScore                        // The match runs until every commander is out; the top score wins
+)
+
+// String returns the condition's name as used in URLs and saves
+func (w WinCondition) String() string {
+	if w == HighScore {
+		return "score"
+	}
+	return "last"
+}
+
+// ParseWinCondition parses a condition name as returned by
+// WinCondition.String. An empty name means last one standing.
+func ParseWinCondition(name string) (WinCondition, error) {
+	switch name {
+	case "", "last":
+		return LastStanding, nil
+	case "score":
+		return HighScore, nil
+	}
+	return LastStanding, fmt.Errorf("game: unknown win condition %q", name)
+}
+
+// newPlayers creates n players with default names and colors
+func newPlayers(n int) []*Player {
+	players := make([]*Player, n)
+	for i := range players {
+		players[i] = &Player{ID: i, Name: fmt.Sprintf("Player %d", i+1), Color: playerColors[i], Direction: Right, LastMoved: Right}
+	}
+	return players
+}
+
+// spawnPoint returns where player i of n starts a level and which way it
+// heads. A lone commander starts in the middle; several start in a pinwheel
+// around it so nobody is heading straight at anyone else.
+func (g *Game) spawnPoint(i, n int) (Position, Direction) {
+	if n == 1 {
+		return Position{X: g.Width / 2, Y: g.Height / 2}, Right
+	}
+	switch i {
+	case 0:
+		return Position{X: g.Width / 4, Y: g.Height / 2}, Up
+	case 1:
+		return Position{X: g.Width - 1 - g.Width/4, Y: g.Height / 2}, Down
+	case 2:
+		return Position{X: g.Width / 2, Y: g.Height / 4}, Right
+	}
+	return Position{X: g.Width / 2, Y: g.Height - 1 - g.Height/4}, Left
+}
+
+// resetPlayers puts every commander still in the match back on its spawn
+// point with a fresh trail for a new level. A lone commander keeps heading
+// the way it last went; several are turned to their spawn headings.
+func (g *Game) resetPlayers() {
+	for _, p := range g.Players {
+		p.Trail = make([]Position, 0)
+		p.Turns = nil
+		p.Collected = 0
+		p.Combo, p.ComboTicksLeft = 0, 0
+		if p.Out {
+			continue
+		}
+		var heading Direction
+		p.Commander, heading = g.spawnPoint(p.ID, len(g.Players))
+		if len(g.Players) > 1 {
+			p.Direction, p.LastMoved = heading, heading
+		}
+	}
+}
+
+// active returns the players still in the match, in player order
+func (g *Game) active() []*Player {
+	players := make([]*Player, 0, len(g.Players))
+	for _, p := range g.Players {
+		if !p.Out {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+// isCommander reports whether a commander still in the match stands on pos
+func (g *Game) isCommander(pos Position) bool { return g.commanderAt(pos) != nil }
+
+// commanderAt returns the player whose commander, still in the match,
+// stands on pos, or nil
+func (g *Game) commanderAt(pos Position) *Player {
+	for _, p := range g.Players {
+		if !p.Out && p.Commander == pos {
+			return p
+		}
+	}
+	return nil
+}
+
+// nearestCommander returns the distance from pos to the closest commander
+// still in the match
+func (g *Game) nearestCommander(pos Position) int {
+	nearest := g.Width + g.Height
+	for _, p := range g.active() {
+		nearest = min(nearest, g.distance(pos, p.Commander))
+	}
+	return nearest
+}
+
+// checkHeadOn knocks out commanders that moved into the same cell this
+// tick. Commanders that swapped cells run into each other's fresh trail and
+// are caught by checkCollisions.
+func (g *Game) checkHeadOn(result *StepResult) {
+	hit := make([]bool, len(g.Players))
+	for i, a := range g.Players {
+		for j := i + 1; j < len(g.Players); j++ {
+			if b := g.Players[j]; !a.Out && !b.Out && a.Commander == b.Commander {
+				hit[i], hit[j] = true, true
+			}
+		}
+	}
+	for i, p := range g.Players {
+		if hit[i] {
+			g.collide(p, CollisionCommander, &result.Players[i])
+		}
+	}
+}
+
+// checkMatchOver ends the game once its win condition is met: when the lone
+// commander is out, when one commander is left standing, or when every
+// commander is out under HighScore
+func (g *Game) checkMatchOver() {
+	if g.State != Playing {
+		return
+	}
+	left := len(g.active())
+	switch {
+	case left == 0:
+	case len(g.Players) > 1 && left == 1 && g.cfg.win == LastStanding:
+	default:
+		return
+	}
+	g.State = GameOver
+	g.settleWinner()
+	g.emit(Event{Kind: EventGameOver, Level: g.Level, Score: g.Score, Player: g.Winner})
+}
+
+// settleWinner records the winner of a finished match: the last commander
+// standing or the highest score, depending on the win condition. Ties are a
+// draw and solo games have no winner; both leave Winner at -1.
+func (g *Game) settleWinner() {
+	g.Winner = -1
+	if len(g.Players) < 2 {
+		return
+	}
+	candidates := g.Players
+	if g.cfg.win == LastStanding {
+		candidates = g.active()
+	}
+	best := -1
+	for _, p := range candidates {
+		switch {
+		case best == -1 || p.Score > g.Players[best].Score:
+			best, g.Winner = p.ID, p.ID
+		case p.Score == g.Players[best].Score:
+			g.Winner = -1
+		}
+	}
+}
+
+// GetPlayers returns every commander in the game, in player order
+func (g *Game) GetPlayers() []*Player { return g.Players }
+
+// GetWinner returns the ID of the player who won the match, or -1 while the
+// match is on, after a draw and in solo games
+func (g *Game) GetWinner() int { return g.Winner }
+
+// GetWinCondition returns how the match is decided
+func (g *Game) GetWinCondition() WinCondition { return g.cfg.win }
@@ -0,0 +1,31 @@
+package game
+
+// splitMix64 is a small, cheap-to-seed rand.Source64 so every Game can own
+// its own generator without sharing the global math/rand state.
+type splitMix64 struct {
+	state uint64
+}
+
+// newSource creates a source seeded with the given value
+func newSource(seed int64) *splitMix64 {
+	return &splitMix64{state: uint64(seed)}
+}
+
+// Seed resets the source to the given seed
+func (s *splitMix64) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 returns the next pseudo-random 64-bit value
+func (s *splitMix64) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer
+func (s *splitMix64) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
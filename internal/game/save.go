@@ -0,0 +1,193 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// SaveVersion is the current save format version
+const SaveVersion = 1
+
+// ErrUnsupportedSave is returned when a save was written by another version
+var ErrUnsupportedSave = errors.New("game: unsupported save version")
+
+// ErrPackMismatch is returned when a save is loaded with another level pack
+// than the one it was made on, whose layouts it would not match
+var ErrPackMismatch = errors.New("game: save was made on another level pack")
+
+// saveState is the serialized form of a Game. Timers are stored relative to
+// the moment of saving so a resumed game does not count the time it was away.
+type saveState struct {
+	Version         int              `json:"version"`
+	Seed            int64            `json:"seed"`
+	RNG             uint64           `json:"rng"`
+	Width           int              `json:"width"`
+	Height          int              `json:"height"`
+	PackName        string           `json:"pack_name,omitempty"`
+	PackHash        string           `json:"pack_hash"` // Fingerprint of the level pack, see LevelPack.hash
+	Players         []*Player        `json:"players,omitempty"`
+	Win             string           `json:"win,omitempty"`
+	Winner          int              `json:"winner,omitempty"`
+	Alerts          []Alert          `json:"alerts"`
+	Obstacles       []Position       `json:"obstacles"`
+	MovingObstacles []MovingObstacle `json:"moving_obstacles,omitempty"`
+	Breakers        []BreakerWall    `json:"breakers,omitempty"`
+	SpeedModifiers  []SpeedModifier  `json:"speed_modifiers,omitempty"`
+	Results         []LevelResult    `json:"results,omitempty"`
+	Endless         bool             `json:"endless,omitempty"`
+	Mode            GameMode         `json:"mode,omitempty"`
+	Edges           string           `json:"edges,omitempty"` // Edge policy set with WithEdges, if any
+	State           GameState        `json:"state"`
+	Score           int              `json:"score"`
+	Level           int              `json:"level"`
+	AlertsCollected int              `json:"alerts_collected"`
+	AlertsNeeded    int              `json:"alerts_needed"`
+	Tick            int              `json:"tick"`
+	LevelElapsed    int64            `json:"level_elapsed_ms"`
+	CompleteElapsed int64            `json:"complete_elapsed_ms,omitempty"`
+}
+
+// Marshal serializes the complete game state, including the position of the
+// random source, so Unmarshal continues exactly where the game left off
+func Marshal(g *Game) ([]byte, error) {
+	s := saveState{
+		Version:         SaveVersion,
+		Seed:            g.cfg.seed,
+		RNG:             g.src.state,
+		Width:           g.Width,
+		Height:          g.Height,
+		PackName:        g.cfg.pack.Name,
+		PackHash:        g.packHash(),
+		Players:         g.Players,
+		Winner:          g.Winner,
+		Alerts:          g.Alerts,
+		Obstacles:       g.Obstacles,
+		MovingObstacles: g.MovingObstacles,
+		Breakers:        g.Breakers,
+		SpeedModifiers:  g.SpeedModifiers,
+		Results:         g.Results,
+		Endless:         g.cfg.endless,
+		Mode:            g.cfg.mode,
+		State:           g.State,
+		Score:           g.Score,
+		Level:           g.Level,
+		AlertsCollected: g.AlertsCollected,
+		AlertsNeeded:    g.AlertsNeeded,
+		Tick:            g.Tick,
+		LevelElapsed:    g.since(g.StartTime).Milliseconds(),
+	}
+	if g.State == LevelComplete {
+		s.CompleteElapsed = g.since(g.LevelCompleteTime).Milliseconds()
+	}
+	if g.cfg.hasEdges {
+		s.Edges = g.cfg.edges.String()
+	}
+	if len(g.Players) > 1 {
+		s.Win = g.cfg.win.String()
+	}
+	return json.Marshal(s)
+}
+
+// Unmarshal restores a game written by Marshal. Options such as WithClock
+// apply to the restored game; the saved seed always wins over WithSeed.
+func Unmarshal(data []byte, opts ...Option) (*Game, error) {
+	var s saveState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("game: invalid save: %w", err)
+	}
+	if s.Version != SaveVersion {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedSave, s.Version)
+	}
+	if s.Width <= 0 || s.Height <= 0 || s.Level < 1 {
+		return nil, errors.New("game: invalid save: bad dimensions or level")
+	}
+
+	cfg := newConfig(opts)
+	cfg.seed = s.Seed
+	cfg.endless = cfg.endless || s.Endless
+	cfg.mode = s.Mode
+	if s.Edges != "" {
+		edges, err := ParseEdgePolicy(s.Edges)
+		if err != nil {
+			return nil, fmt.Errorf("game: invalid save: %w", err)
+		}
+		cfg.edges, cfg.hasEdges = edges, true
+	}
+	if cfg.packHash = cfg.pack.hash(); cfg.packHash != s.PackHash {
+		return nil, fmt.Errorf("%w %q", ErrPackMismatch, s.PackName)
+	}
+	if s.Level > len(cfg.pack.Levels) && !cfg.endless {
+		return nil, errors.New("game: invalid save: level is not in the level pack")
+	}
+	win, err := ParseWinCondition(s.Win)
+	if err != nil {
+		return nil, fmt.Errorf("game: invalid save: %w", err)
+	}
+	cfg.win = win
+
+	players := s.Players
+	if len(players) == 0 || len(players) > MaxPlayers {
+		return nil, errors.New("game: invalid save: bad number of players")
+	}
+	for i, p := range players {
+		p.ID = i
+		p.Trail = orEmpty(p.Trail)
+	}
+	cfg.players = len(players)
+	now := cfg.clock.Now()
+
+	src := newSource(s.Seed)
+	src.state = s.RNG
+
+	g := &Game{
+		Width:           s.Width,
+		Height:          s.Height,
+		Players:         players,
+		Alerts:          s.Alerts,
+		Obstacles:       orEmpty(s.Obstacles),
+		MovingObstacles: s.MovingObstacles,
+		Breakers:        s.Breakers,
+		SpeedModifiers:  s.SpeedModifiers,
+		Results:         s.Results,
+		State:           s.State,
+		Score:           s.Score,
+		Winner:          s.Winner,
+		Level:           s.Level,
+		AlertsCollected: s.AlertsCollected,
+		AlertsNeeded:    s.AlertsNeeded,
+		Tick:            s.Tick,
+		StartTime:       now.Add(-time.Duration(s.LevelElapsed) * time.Millisecond),
+		LastUpdate:      now,
+		cfg:             cfg,
+		src:             src,
+		rng:             rand.New(src),
+	}
+	if g.Alerts == nil {
+		g.Alerts = make([]Alert, 0)
+	}
+	g.buildEndlessLevel()
+	g.rebuildGrid()
+	if g.State == LevelComplete {
+		g.LevelCompleteTime = now.Add(-time.Duration(s.CompleteElapsed) * time.Millisecond)
+	}
+	return g, nil
+}
+
+// packHash returns the fingerprint of the game's level pack
+func (g *Game) packHash() string {
+	if g.cfg.packHash == "" {
+		g.cfg.packHash = g.cfg.pack.hash()
+	}
+	return g.cfg.packHash
+}
+
+// orEmpty returns an empty slice in place of nil
+func orEmpty(positions []Position) []Position {
+	if positions == nil {
+		return make([]Position, 0)
+	}
+	return positions
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// marshal saves g, failing the test on error
+func marshal(t *testing.T, g *Game) string {
+	t.Helper()
+	data, err := Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	turns := []Direction{Up, Left, Down, Right}
+	for _, players := range []int{1, 2, 4} {
+		for _, level := range []int{1, 2, 3, 4, 5, 6, 7, 11, 12} {
+			clock := NewManualClock(time.Unix(0, 0))
+			g := New(20, 20, WithSeed(int64(level)), WithClock(clock), WithPlayers(players), WithEdges(EdgeWrap), WithEndless())
+			for g.Level < level {
+				g.nextLevel()
+			}
+			for i := 0; i < 12; i++ {
+				clock.Advance(100 * time.Millisecond)
+				g.Step([]Command{PlayerDirectionCommand(i%players, turns[i%len(turns)])})
+			}
+
+			saved := marshal(t, g)
+			restored, err := Unmarshal([]byte(saved), WithClock(clock))
+			if err != nil {
+				t.Fatalf("%d players, level %d: %v", players, level, err)
+			}
+			if got := marshal(t, restored); got != saved {
+				t.Fatalf("%d players, level %d: restored game saves differently:\n got %s\nwant %s", players, level, got, saved)
+			}
+
+			// The restored game must carry on exactly like the original
+			for i := 0; i < 30; i++ {
+				clock.Advance(100 * time.Millisecond)
+				cmds := []Command{PlayerDirectionCommand(i%players, turns[(i/3)%len(turns)])}
+				g.Step(cmds)
+				restored.Step(cmds)
+			}
+			if got, want := marshal(t, restored), marshal(t, g); got != want {
+				t.Fatalf("%d players, level %d: restored game diverged:\n got %s\nwant %s", players, level, got, want)
+			}
+		}
+	}
+}
+
+func TestSavePackMismatch(t *testing.T) {
+	pack := *DefaultLevelPack()
+	pack.Name = "custom"
+	pack.Levels = append([]Level(nil), pack.Levels...)
+	pack.Levels[0].Layout.Cells = [][2]int{{1, 1}}
+
+	clock := NewManualClock(time.Unix(0, 0))
+	data, err := Marshal(New(20, 20, WithSeed(1), WithClock(clock), WithLevelPack(&pack)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unmarshal(data, WithClock(clock)); !errors.Is(err, ErrPackMismatch) {
+		t.Fatalf("loading with the default pack: got %v, want %v", err, ErrPackMismatch)
+	}
+
+	// A pack sent over the wire, as online rooms and replays do, still matches
+	raw, err := json.Marshal(&pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := LoadLevelPack(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unmarshal(data, WithClock(clock), WithLevelPack(decoded)); err != nil {
+		t.Fatalf("loading with the decoded pack: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Alert spawn policies
+const (
+	SpawnUniform     = "uniform"      // Any free cell, all equally likely
+	SpawnAway        = "away"         // Free cells far from every commander are more likely
+	SpawnHotspots    = "hotspots"     // Alerts cluster around the level's hotspots
+	SpawnMinDistance = "min_distance" // Any free cell at least min_distance from every commander
+)
+
+// Spawn policy defaults
+const (
+	defaultMinDistance = 5 // Cells kept between the commanders and new alerts
+	hotspotRadius      = 6 // Distance over which a hotspot attracts alerts
+	uniformAttempts    = 8 // Draws before uniform spawning scans for a cell
+)
+
+// SpawnRules configures where a level spawns its alerts
+type SpawnRules struct {
+	Policy      string   `json:"policy,omitempty"`       // uniform (default), away, hotspots or min_distance
+	MinDistance int      `json:"min_distance,omitempty"` // Minimum distance for min_distance; 0 uses the default
+	Hotspots    [][2]int `json:"hotspots,omitempty"`     // Cells alerts cluster around; defaults to the quadrant centres
+}
+
+// validate checks the spawn policy is known
+func (s SpawnRules) validate() error {
+	switch s.Policy {
+	case "", SpawnUniform, SpawnAway, SpawnHotspots, SpawnMinDistance:
+	default:
+		return fmt.Errorf("unknown spawn policy %q", s.Policy)
+	}
+	if s.MinDistance < 0 {
+		return errors.New("spawn min_distance must not be negative")
+	}
+	return nil
+}
+
+// canSpawnAt reports whether an alert may appear at the free grid cell pos
+func (g *Game) canSpawnAt(pos Position) bool {
+	return !g.isCommander(pos) && !g.hitsMovingObstacle(pos) && !g.hitsArmedBreaker(pos)
+}
+
+// pickSpawnCell chooses a free cell for a new alert under the level's spawn
+// policy. It never loops unbounded and reports false when no cell is free.
+func (g *Game) pickSpawnCell() (Position, bool) {
+	rules := g.levelDef().Spawn
+	switch rules.Policy {
+	case SpawnAway:
+		return g.pickWeighted(func(p Position) int {
+			return 1 + g.nearestCommander(p)
+		})
+	case SpawnHotspots:
+		hotspots := g.hotspots(rules)
+		return g.pickWeighted(func(p Position) int {
+			nearest := hotspotRadius
+			for _, h := range hotspots {
+				nearest = min(nearest, g.distance(p, h))
+			}
+			weight := hotspotRadius + 1 - nearest
+			return weight * weight
+		})
+	case SpawnMinDistance:
+		minDistance := rules.MinDistance
+		if minDistance == 0 {
+			minDistance = defaultMinDistance
+		}
+		if pos, ok := g.pickWeighted(func(p Position) int {
+			if g.nearestCommander(p) < minDistance {
+				return 0
+			}
+			return 1
+		}); ok {
+			return pos, true
+		}
+	}
+	return g.pickUniform()
+}
+
+// pickUniform draws a free cell with every cell equally likely. The few
+// cells taken by commanders, moving obstacles and breaker walls are
+// rejected; after a handful of rejections the remaining cells are scanned.
+func (g *Game) pickUniform() (Position, bool) {
+	free := g.free.count()
+	if free == 0 {
+		return Position{}, false
+	}
+	for attempt := 0; attempt < uniformAttempts; attempt++ {
+		i := g.free.nth(g.rng.Intn(free))
+		if pos := (Position{X: i % g.Width, Y: i / g.Width}); g.canSpawnAt(pos) {
+			return pos, true
+		}
+	}
+	return g.pickWeighted(func(Position) int { return 1 })
+}
+
+// pickWeighted draws a free cell with probability proportional to weight.
+// Cells with weight 0 are never picked.
+func (g *Game) pickWeighted(weight func(Position) int) (Position, bool) {
+	var cells []Position
+	var weights []int
+	total := 0
+	for i, c := range g.grid {
+		pos := Position{X: i % g.Width, Y: i / g.Width}
+		if c != CellEmpty || !g.canSpawnAt(pos) {
+			continue
+		}
+		if w := weight(pos); w > 0 {
+			cells = append(cells, pos)
+			weights = append(weights, w)
+			total += w
+		}
+	}
+	if total == 0 {
+		return Position{}, false
+	}
+	roll := g.rng.Intn(total)
+	for i, w := range weights {
+		if roll < w {
+			return cells[i], true
+		}
+		roll -= w
+	}
+	return cells[len(cells)-1], true
+}
+
+// hotspots returns the level's hotspots, or the centres of the four board
+// quadrants when it declares none
+func (g *Game) hotspots(rules SpawnRules) []Position {
+	if len(rules.Hotspots) == 0 {
+		return []Position{
+			{X: g.Width / 4, Y: g.Height / 4}, {X: 3 * g.Width / 4, Y: g.Height / 4},
+			{X: g.Width / 4, Y: 3 * g.Height / 4}, {X: 3 * g.Width / 4, Y: 3 * g.Height / 4},
+		}
+	}
+	hotspots := make([]Position, len(rules.Hotspots))
+	for i, h := range rules.Hotspots {
+		hotspots[i] = Position{X: h[0], Y: h[1]}
+	}
+	return hotspots
+}
+
+// distance returns the number of moves between a and b, taking the short
+// way across wrapping edges
+func (g *Game) distance(a, b Position) int {
+	dx, dy := abs(a.X-b.X), abs(a.Y-b.Y)
+	if g.GetEdges() == EdgeWrap {
+		dx, dy = min(dx, g.Width-dx), min(dy, g.Height-dy)
+	}
+	return dx + dy
+}
+
+// saturate ends the game when the board has no room for the alerts the
+// level still needs
+func (g *Game) saturate() {
+	g.State = Saturated
+	g.settleWinner()
+	g.emit(Event{Kind: EventBoardSaturated, Level: g.Level, Score: g.Score})
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoardSaturates(t *testing.T) {
+	// A single wrapping corridor: the trail fills it within a lap
+	pack := &LevelPack{Name: "Corridor", Levels: []Level{{
+		Name:           "Corridor",
+		Width:          5,
+		Height:         5,
+		TickIntervalMS: 100,
+		AlertsNeeded:   100,
+		AlertsOnScreen: 1,
+		AckTicks:       [3]int{1000, 1000, 1000},
+		Edges:          "wrap",
+		Layout:         Layout{Map: []string{"#####", "#####", ".....", "#####", "#####"}},
+	}}}
+	if err := pack.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	clock := NewManualClock(time.Unix(0, 0))
+	g := New(5, 5, WithSeed(1), WithClock(clock), WithLevelPack(pack))
+
+	saturated := 0
+	for i := 0; i < 10 && g.GetState() == Playing; i++ {
+		clock.Advance(g.GetTickInterval())
+		result := g.Step(nil)
+		if result.Collision != CollisionNone {
+			t.Fatalf("tick %d: commander collided with %v before the board filled", result.Tick, result.Collision)
+		}
+		for _, e := range result.Events {
+			if e.Kind == EventBoardSaturated {
+				saturated++
+			}
+		}
+	}
+	if g.GetState() != Saturated || saturated != 1 {
+		t.Fatalf("state %v with %d saturation events, want Saturated with 1", g.GetState(), saturated)
+	}
+
+	// A saturated game stays put
+	clock.Advance(g.GetTickInterval())
+	if result := g.Step(nil); result.State != Saturated || len(result.Events) != 0 {
+		t.Fatalf("saturated game went on to %v with events %v", result.State, result.Events)
+	}
+}
+
+// spawnDistances draws many alert cells under rules on an open board and
+// returns how far each is from the nearest commander and from the nearest
+// of the level's hotspots
+func spawnDistances(t *testing.T, rules SpawnRules) (commander, hotspot []int) {
+	t.Helper()
+	for seed := int64(1); seed <= 20; seed++ {
+		pack := openPack(20, 20)
+		pack.Levels[0].Spawn = rules
+		g := New(20, 20, WithSeed(seed), WithClock(NewManualClock(time.Unix(0, 0))), WithLevelPack(pack))
+		hotspots := g.hotspots(rules)
+		for i := 0; i < 100; i++ {
+			pos, ok := g.pickSpawnCell()
+			if !ok {
+				t.Fatalf("%s: no cell to spawn on", rules.Policy)
+			}
+			if g.CellAt(pos) != CellEmpty || !g.canSpawnAt(pos) {
+				t.Fatalf("%s: picked %v, which is taken", rules.Policy, pos)
+			}
+			nearest := g.Width + g.Height
+			for _, h := range hotspots {
+				nearest = min(nearest, g.distance(pos, h))
+			}
+			commander = append(commander, g.nearestCommander(pos))
+			hotspot = append(hotspot, nearest)
+		}
+	}
+	return commander, hotspot
+}
+
+// mean returns the average of values
+func mean(values []int) float64 {
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	return float64(sum) / float64(len(values))
+}
+
+func TestSpawnPolicies(t *testing.T) {
+	uniformCommander, uniformHotspot := spawnDistances(t, SpawnRules{Policy: SpawnUniform})
+
+	t.Run("min_distance", func(t *testing.T) {
+		for _, minDistance := range []int{0, 3, 8} {
+			commander, _ := spawnDistances(t, SpawnRules{Policy: SpawnMinDistance, MinDistance: minDistance})
+			want := minDistance
+			if want == 0 {
+				want = defaultMinDistance
+			}
+			for _, d := range commander {
+				if d < want {
+					t.Fatalf("min_distance %d: alert spawned %d cells from the commander", minDistance, d)
+				}
+			}
+		}
+	})
+	t.Run("away", func(t *testing.T) {
+		commander, _ := spawnDistances(t, SpawnRules{Policy: SpawnAway})
+		if got, uniform := mean(commander), mean(uniformCommander); got < uniform+1 {
+			t.Errorf("alerts spawn %.1f cells from the commander on average, want well over uniform's %.1f", got, uniform)
+		}
+	})
+	t.Run("hotspots", func(t *testing.T) {
+		rules := SpawnRules{Policy: SpawnHotspots, Hotspots: [][2]int{{3, 3}, {16, 5}}}
+		_, hotspot := spawnDistances(t, rules)
+		_, uniform := spawnDistances(t, SpawnRules{Policy: SpawnUniform, Hotspots: rules.Hotspots})
+		if got, want := mean(hotspot), mean(uniform); got > want-1 {
+			t.Errorf("alerts spawn %.1f cells from a hotspot on average, want well under uniform's %.1f", got, want)
+		}
+		if _, got := spawnDistances(t, SpawnRules{Policy: SpawnHotspots}); mean(got) > mean(uniformHotspot)-1 {
+			t.Errorf("alerts spawn %.1f cells from a quadrant centre on average, want well under uniform's %.1f", mean(got), mean(uniformHotspot))
+		}
+	})
+}
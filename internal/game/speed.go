@@ -0,0 +1,72 @@
+package game
+
+import "time"
+
+// minTickInterval bounds how fast speed modifiers can make the game run
+const minTickInterval = 20 * time.Millisecond
+
+// SpeedModifier scales the tick interval while it is active. Factors below 1
+// speed the game up and factors above 1 slow it down.
+type SpeedModifier struct {
+	Name      string  `json:"name"`
+	Factor    float64 `json:"factor"`
+	Remaining int     `json:"remaining"` // Ticks left, 0 lasts until cleared or the level ends
+}
+
+// Boost returns a modifier that doubles the game speed for the given ticks
+func Boost(ticks int) SpeedModifier {
+	return SpeedModifier{Name: "boost", Factor: 0.5, Remaining: ticks}
+}
+
+// SlowMotion returns a modifier that halves the game speed for the given ticks
+func SlowMotion(ticks int) SpeedModifier {
+	return SpeedModifier{Name: "slow-motion", Factor: 2, Remaining: ticks}
+}
+
+// GetTickInterval returns how long each tick lasts on the current level and
+// mode with all active speed modifiers applied. Front ends should step the game once
+// per interval.
+func (g *Game) GetTickInterval() time.Duration {
+	interval := float64(g.levelDef().TickIntervalMS) * float64(time.Millisecond)
+	interval *= float64(g.cfg.mode.rules().tickPercent) / 100
+	for _, m := range g.SpeedModifiers {
+		interval *= m.Factor
+	}
+	if time.Duration(interval) < minTickInterval {
+		return minTickInterval
+	}
+	return time.Duration(interval)
+}
+
+// AddSpeedModifier activates a speed modifier; modifiers stack multiplicatively
+func (g *Game) AddSpeedModifier(m SpeedModifier) {
+	if m.Factor <= 0 {
+		return
+	}
+	g.SpeedModifiers = append(g.SpeedModifiers, m)
+}
+
+// ClearSpeedModifiers removes all active speed modifiers
+func (g *Game) ClearSpeedModifiers() {
+	g.SpeedModifiers = nil
+}
+
+// GetSpeedModifiers returns the active speed modifiers
+func (g *Game) GetSpeedModifiers() []SpeedModifier {
+	return g.SpeedModifiers
+}
+
+// updateSpeedModifiers counts down timed modifiers and drops expired ones
+func (g *Game) updateSpeedModifiers() {
+	active := g.SpeedModifiers[:0]
+	for _, m := range g.SpeedModifiers {
+		if m.Remaining > 0 {
+			m.Remaining--
+			if m.Remaining == 0 {
+				continue
+			}
+		}
+		active = append(active, m)
+	}
+	g.SpeedModifiers = active
+}
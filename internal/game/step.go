@@ -0,0 +1,144 @@
+package game
+
+// CommandKind identifies what a Command asks the game to do
+type CommandKind int
+
+const (
+	CommandDirection CommandKind = iota
+	CommandPause
+	CommandRestart
+)
+
+// Command is a single player input applied by Apply or Step
+type Command struct {
+	Kind      CommandKind
+	Direction Direction // Only used by CommandDirection
+	Player    int       // ID of the player turning; only used by CommandDirection
+}
+
+// DirectionCommand returns a command that turns the first player's commander
+func DirectionCommand(dir Direction) Command {
+	return Command{Kind: CommandDirection, Direction: dir}
+}
+
+// PlayerDirectionCommand returns a command that turns the given player's
+// commander
+func PlayerDirectionCommand(player int, dir Direction) Command {
+	return Command{Kind: CommandDirection, Direction: dir, Player: player}
+}
+
+// PauseCommand returns a command that toggles pause
+func PauseCommand() Command {
+	return Command{Kind: CommandPause}
+}
+
+// RestartCommand returns a command that restarts the game
+func RestartCommand() Command {
+	return Command{Kind: CommandRestart}
+}
+
+// CollisionKind identifies what the commander ran into
+type CollisionKind int
+
+const (
+	CollisionNone CollisionKind = iota
+	CollisionWall
+	CollisionTrail
+	CollisionObstacle
+	CollisionCommander // Moved into the same cell as another commander
+)
+
+// PlayerStep describes what happened to one commander during a single tick
+type PlayerStep struct {
+	Moved       bool          // Whether the commander moved this tick
+	From, To    Position      // Commander position before and after the move
+	Collected   bool          // Whether an alert was collected
+	CollectedAt Position      // Position of the collected alert
+	Collision   CollisionKind // What the commander hit, if anything
+	NearMiss    bool          // Whether the commander passed right next to a hazard
+}
+
+// StepResult describes what happened during a single tick. The embedded
+// PlayerStep is the first player's; Players has every player's.
+type StepResult struct {
+	PlayerStep
+	Tick         int          // Tick number after the step
+	Players      []PlayerStep // What happened to each commander, by player ID
+	LevelChanged bool         // Whether the level changed during the step
+	Level        int          // Level after the step
+	State        GameState    // State after the step
+	Events       []Event      // Events emitted during the step, in order
+}
+
+// Apply applies commands without advancing the game. Commands applied
+// between two ticks behave exactly as if they were passed to the next Step.
+func (g *Game) Apply(cmds []Command) {
+	for _, cmd := range cmds {
+		switch cmd.Kind {
+		case CommandDirection:
+			g.SetPlayerDirection(cmd.Player, cmd.Direction)
+		case CommandPause:
+			g.Pause()
+		case CommandRestart:
+			g.Restart()
+		}
+	}
+}
+
+// Step applies the commands and advances the game by exactly one tick.
+// It needs no browser APIs, so bots, tests and the server can drive a Game
+// directly; pair it with a ManualClock to run faster than real time.
+func (g *Game) Step(cmds []Command) StepResult {
+	g.bus.stepping = true
+	g.bus.collected = nil
+	defer func() { g.bus.stepping = false }()
+
+	g.Apply(cmds)
+
+	level := g.Level
+	g.Tick++
+	result := StepResult{Tick: g.Tick, Players: make([]PlayerStep, len(g.Players))}
+
+	g.LastUpdate = g.cfg.clock.Now()
+
+	// Always check level completion for timer-based transitions
+	g.checkLevelComplete()
+
+	// Walls and moving obstacles go first; moving obstacles may run into
+	// the commander
+	if g.State == Playing {
+		g.updateSpeedModifiers()
+		g.ageAlerts()
+		g.updateBreakers()
+		g.moveObstacles(&result)
+		g.checkMatchOver()
+	}
+
+	// Only move and check collisions when playing. Every commander moves
+	// before any collision is checked, so the order players are listed in
+	// never decides who ran into whom.
+	if g.State == Playing {
+		for _, p := range g.active() {
+			step := &result.Players[p.ID]
+			step.From = p.Commander
+			g.moveCommander(p)
+			step.Moved = true
+			step.To = p.Commander
+		}
+
+		g.checkHeadOn(&result)
+		for _, p := range g.active() {
+			g.checkCollisions(p, &result.Players[p.ID])
+		}
+		g.checkMatchOver()
+	}
+
+	if len(result.Players) > 0 {
+		result.PlayerStep = result.Players[0]
+	}
+	result.Level = g.Level
+	result.LevelChanged = g.Level != level
+	result.State = g.State
+	result.Events = g.bus.collected
+	return result
+}
@@ -0,0 +1,43 @@
+package game
+
+// maxQueuedTurns bounds how many turns can wait for later ticks
+const maxQueuedTurns = 3
+
+// opposites maps each direction to its reverse
+var opposites = map[Direction]Direction{
+	Up: Down, Down: Up, Left: Right, Right: Left,
+}
+
+// queueTurn buffers a turn of the player's commander for a coming tick.
+// Turns are checked against the direction the commander will be heading
+// when they apply, so turns that would reverse into the trail or repeat
+// that heading are dropped, as are turns beyond the queue limit.
+func (g *Game) queueTurn(p *Player, dir Direction) {
+	heading := p.LastMoved
+	if n := len(p.Turns); n > 0 {
+		heading = p.Turns[n-1]
+	}
+	if dir == heading || dir == opposites[heading] || len(p.Turns) >= maxQueuedTurns {
+		return
+	}
+	p.Turns = append(p.Turns, dir)
+}
+
+// applyTurn takes the next queued turn, if any, and makes it the direction
+// of this tick's move. At most one turn applies per tick; the rest carry
+// over in order.
+func (g *Game) applyTurn(p *Player) {
+	for len(p.Turns) > 0 {
+		dir := p.Turns[0]
+		p.Turns = p.Turns[1:]
+		// An edge bounce may have changed the heading since the turn was queued
+		if dir != p.LastMoved && dir != opposites[p.LastMoved] {
+			p.Direction = dir
+			return
+		}
+	}
+}
+
+// GetQueuedTurns returns the first player's turns waiting for coming ticks,
+// oldest first
+func (g *Game) GetQueuedTurns() []Direction { return g.Players[0].Turns }
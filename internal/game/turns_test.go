@@ -0,0 +1,54 @@
+package game
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTurnsQueuedWithinOneTick(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	g := New(20, 20, WithSeed(1), WithClock(clock), WithLevelPack(openPack(20, 20)))
+	placeAlerts(g, Position{X: 18, Y: 18})
+
+	// Heading right from (10, 10), Up then Left is a quick U-turn; taken on
+	// one tick, Left would reverse into the trail
+	g.Apply([]Command{DirectionCommand(Up), DirectionCommand(Left)})
+	for i, want := range []Position{{X: 10, Y: 9}, {X: 9, Y: 9}} {
+		clock.Advance(g.GetTickInterval())
+		result := g.Step(nil)
+		if result.Collision != CollisionNone {
+			t.Fatalf("tick %d: commander collided with %v", result.Tick, result.Collision)
+		}
+		if got := g.GetCommander(); got != want {
+			t.Fatalf("tick %d: commander at %v, want %v", i+1, got, want)
+		}
+	}
+	if got := g.Players[0].LastMoved; got != Left {
+		t.Errorf("heading %v, want %v", got, Left)
+	}
+}
+
+func TestQueueTurnDrops(t *testing.T) {
+	tests := []struct {
+		name  string
+		turns []Direction
+		want  []Direction
+	}{
+		{name: "reversal", turns: []Direction{Left}, want: nil},
+		{name: "same heading", turns: []Direction{Right}, want: nil},
+		{name: "repeated turn", turns: []Direction{Up, Up}, want: []Direction{Up}},
+		{name: "past the limit", turns: []Direction{Up, Left, Down, Right}, want: []Direction{Up, Left, Down}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(20, 20, WithSeed(1), WithClock(NewManualClock(time.Unix(0, 0))), WithLevelPack(openPack(20, 20)))
+			for _, dir := range tt.turns {
+				g.Apply([]Command{DirectionCommand(dir)})
+			}
+			if got := g.GetQueuedTurns(); !slices.Equal(got, tt.want) {
+				t.Errorf("queued %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package game
+
+import "fmt"
+
+// maxLayoutAttempts bounds how often a random layout is regenerated before
+// the last attempt is repaired instead
+const maxLayoutAttempts = 20
+
+// LayoutReport is the result of a reachability analysis of a board
+type LayoutReport struct {
+	Reachable      int          // Free cells the commander can reach from the spawn
+	Unreachable    [][]Position // Connected free regions the commander cannot reach
+	DeadEndSpawn   bool         // The spawn has no safe first move or too few exits
+	EnclosedAlerts []Position   // Alerts placed inside unreachable regions
+}
+
+// OK reports whether the layout is fully playable
+func (r LayoutReport) OK() bool {
+	return len(r.Unreachable) == 0 && !r.DeadEndSpawn && len(r.EnclosedAlerts) == 0
+}
+
+// String summarizes the problems found
+func (r LayoutReport) String() string {
+	if r.OK() {
+		return "layout ok"
+	}
+	cells := 0
+	for _, region := range r.Unreachable {
+		cells += len(region)
+	}
+	return fmt.Sprintf("%d unreachable regions (%d cells), dead-end spawn: %t, %d enclosed alerts",
+		len(r.Unreachable), cells, r.DeadEndSpawn, len(r.EnclosedAlerts))
+}
+
+// ValidateLayout flood-fills the free cells of a width x height board from
+// the spawn cell, with the commander initially heading in direction, and
+// reports every region and alert it cannot reach. With wrapping edges,
+// regions connect across opposite edges. Moving obstacles and breaker walls
+// are not passed in: they never wall a region off for good.
+func ValidateLayout(width, height int, edges EdgePolicy, obstacles []Position, spawn Position, heading Direction, alerts []Position) LayoutReport {
+	blocked := make([]bool, width*height)
+	for _, o := range obstacles {
+		if o.X >= 0 && o.X < width && o.Y >= 0 && o.Y < height {
+			blocked[o.Y*width+o.X] = true
+		}
+	}
+	free := func(p Position) bool {
+		return inBounds(p, width, height) && !blocked[p.Y*width+p.X]
+	}
+	adjacent := func(p Position) [4]Position {
+		cells := neighbours(p)
+		if edges == EdgeWrap {
+			for i := range cells {
+				cells[i] = wrap(cells[i], width, height)
+			}
+		}
+		return cells
+	}
+
+	var report LayoutReport
+	region := make([]int, width*height) // 0 = unvisited, 1 = reachable, 2+ = enclosed region
+	fill := func(start Position, id int) []Position {
+		cells := []Position{start}
+		region[start.Y*width+start.X] = id
+		for i := 0; i < len(cells); i++ {
+			for _, n := range adjacent(cells[i]) {
+				if free(n) && region[n.Y*width+n.X] == 0 {
+					region[n.Y*width+n.X] = id
+					cells = append(cells, n)
+				}
+			}
+		}
+		return cells
+	}
+
+	if !free(spawn) {
+		report.DeadEndSpawn = true
+	} else {
+		report.Reachable = len(fill(spawn, 1))
+
+		exits := 0
+		for _, n := range adjacent(spawn) {
+			if free(n) {
+				exits++
+			}
+		}
+		ahead := spawn.step(heading)
+		if edges == EdgeWrap {
+			ahead = wrap(ahead, width, height)
+		}
+		report.DeadEndSpawn = exits < 2 || !free(ahead)
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Position{X: x, Y: y}
+			if free(p) && region[y*width+x] == 0 {
+				report.Unreachable = append(report.Unreachable, fill(p, len(report.Unreachable)+2))
+			}
+		}
+	}
+
+	for _, a := range alerts {
+		if free(a) && region[a.Y*width+a.X] != 1 {
+			report.EnclosedAlerts = append(report.EnclosedAlerts, a)
+		}
+	}
+	return report
+}
+
+// ValidateBoard runs ValidateLayout on the game's current board from the
+// first player's spawn. The other players' spawns are kept clear by
+// clearSpawn, so they lie in the same region unless a wall cuts them off.
+func (g *Game) ValidateBoard() LayoutReport {
+	p := g.Players[0]
+	return ValidateLayout(g.Width, g.Height, g.GetEdges(), g.Obstacles, p.Commander, p.Direction, g.alertPositions())
+}
+
+// neighbours returns the four orthogonal neighbours of p
+func neighbours(p Position) [4]Position {
+	return [4]Position{
+		{X: p.X, Y: p.Y - 1},
+		{X: p.X, Y: p.Y + 1},
+		{X: p.X - 1, Y: p.Y},
+		{X: p.X + 1, Y: p.Y},
+	}
+}
+
+// step returns the position one cell away in direction dir
+func (p Position) step(dir Direction) Position {
+	switch dir {
+	case Up:
+		p.Y--
+	case Down:
+		p.Y++
+	case Left:
+		p.X--
+	case Right:
+		p.X++
+	}
+	return p
+}
+
+// buildLayout lays out the current level's obstacles and checks the board
+// is playable. Random layouts are regenerated until they pass; a layout that
+// still fails has its enclosed pockets walled off and its spawn cleared.
+func (g *Game) buildLayout() {
+	random := g.levelDef().Layout.isRandom()
+
+	for attempt := 1; ; attempt++ {
+		g.Obstacles = make([]Position, 0)
+		g.resetGrid()
+		g.MovingObstacles = make([]MovingObstacle, 0)
+		g.Breakers = make([]BreakerWall, 0)
+		g.applyLayout()
+		g.clearSpawn()
+		g.rebuildGrid()
+
+		report := g.ValidateBoard()
+		if report.OK() {
+			return
+		}
+		if !random || attempt == maxLayoutAttempts {
+			g.repairLayout(report)
+			return
+		}
+	}
+}
+
+// clearSpawn removes any obstacles at the commander spawn positions, and
+// moving obstacles and breaker walls that reach into the spawn safe zone.
+// With several players, the obstacles around each spawn are cleared too
+// since the generators only keep the middle of the board free.
+func (g *Game) clearSpawn() {
+	spawns := make(map[Position]bool)
+	for _, p := range g.active() {
+		spawns[p.Commander] = true
+		if len(g.Players) > 1 {
+			for _, cell := range spawnGuard(p) {
+				spawns[cell] = true
+			}
+		}
+	}
+	for i := len(g.Obstacles) - 1; i >= 0; i-- {
+		if spawns[g.Obstacles[i]] {
+			// Remove obstacle that would collide with commander
+			g.Obstacles = append(g.Obstacles[:i], g.Obstacles[i+1:]...)
+		}
+	}
+
+	moving := g.MovingObstacles[:0]
+	for _, m := range g.MovingObstacles {
+		if !g.anyInSpawnZone(m.cells()) {
+			moving = append(moving, m)
+		}
+	}
+	g.MovingObstacles = moving
+	breakers := g.Breakers[:0]
+	for _, b := range g.Breakers {
+		if !g.anyInSpawnZone(b.Cells) {
+			breakers = append(breakers, b)
+		}
+	}
+	g.Breakers = breakers
+}
+
+// anyInSpawnZone reports whether any of the cells is inside the spawn safe
+// zone
+func (g *Game) anyInSpawnZone(cells []Position) bool {
+	for _, p := range cells {
+		if g.inSpawnZone(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// repairLayout fixes a layout that failed validation: unreachable pockets
+// become obstacles, so no alert can spawn there, and a dead-end spawn has
+// the obstacles around it removed
+func (g *Game) repairLayout(report LayoutReport) {
+	if report.DeadEndSpawn {
+		around := neighbours(g.Players[0].Commander)
+		for i := len(g.Obstacles) - 1; i >= 0; i-- {
+			for _, n := range around {
+				if g.Obstacles[i] == n {
+					g.Obstacles = append(g.Obstacles[:i], g.Obstacles[i+1:]...)
+					break
+				}
+			}
+		}
+		report = g.ValidateBoard()
+	}
+	for _, region := range report.Unreachable {
+		// A region holding another player's spawn stays open for that player
+		if !g.holdsCommander(region) {
+			g.Obstacles = append(g.Obstacles, region...)
+		}
+	}
+	g.rebuildGrid()
+}
+
+// holdsCommander reports whether a commander still in the match stands in
+// one of the cells
+func (g *Game) holdsCommander(cells []Position) bool {
+	for _, c := range cells {
+		if g.isCommander(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRandom reports whether the layout uses a generator that draws from the
+// random source and can therefore be regenerated
+func (l Layout) isRandom() bool {
+	for _, gen := range l.Generators {
+		if gen.Type == "random" || gen.Type == "maze" || gen.Type == "patrol" || gen.Type == "breakers" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExplicit checks the fixed part of a sized level's layout: its
+// cells and map must leave the whole board reachable from the spawn
+func (l Level) validateExplicit() error {
+	if l.Width == 0 {
+		return nil
+	}
+	for _, spec := range l.Layout.Moving {
+		path, mode, _ := spec.parse()
+		m := newMovingObstacle(path, mode, 1)
+		for _, p := range m.cells() {
+			if p.X >= l.Width || p.Y >= l.Height {
+				return fmt.Errorf("moving obstacle leaves the %dx%d board at %v", l.Width, l.Height, p)
+			}
+			if abs(p.X-l.Width/2) <= 2 && abs(p.Y-l.Height/2) <= 2 {
+				return fmt.Errorf("moving obstacle enters the spawn safe zone at %v", p)
+			}
+		}
+	}
+	for _, spec := range l.Layout.Breakers {
+		cells, _ := spec.parse()
+		for _, p := range cells {
+			if p.X >= l.Width || p.Y >= l.Height {
+				return fmt.Errorf("breaker wall leaves the %dx%d board at %v", l.Width, l.Height, p)
+			}
+			if abs(p.X-l.Width/2) <= 2 && abs(p.Y-l.Height/2) <= 2 {
+				return fmt.Errorf("breaker wall enters the spawn safe zone at %v", p)
+			}
+		}
+	}
+	if len(l.Layout.Cells) == 0 && len(l.Layout.Map) == 0 {
+		return nil
+	}
+	var obstacles []Position
+	for _, cell := range l.Layout.Cells {
+		if cell[0] >= l.Width || cell[1] >= l.Height {
+			return fmt.Errorf("cell %v is outside the %dx%d board", cell, l.Width, l.Height)
+		}
+		obstacles = append(obstacles, Position{X: cell[0], Y: cell[1]})
+	}
+	for y, row := range l.Layout.Map {
+		for x, c := range row {
+			if c == '#' {
+				obstacles = append(obstacles, Position{X: x, Y: y})
+			}
+		}
+	}
+
+	spawn := Position{X: l.Width / 2, Y: l.Height / 2}
+	edges, _ := ParseEdgePolicy(l.Edges)
+	if report := ValidateLayout(l.Width, l.Height, edges, obstacles, spawn, Right, nil); !report.OK() {
+		return fmt.Errorf("unplayable layout: %s", report)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package game
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSpawnsStayClear(t *testing.T) {
+	for seed := int64(1); seed <= 100; seed++ {
+		for _, players := range []int{2, 3, 4} {
+			g := New(20, 20, WithSeed(seed), WithClock(NewManualClock(time.Unix(0, 0))), WithPlayers(players))
+			for g.Level < 7 {
+				g.nextLevel()
+				if g.Level < 5 {
+					continue
+				}
+				for _, p := range g.active() {
+					for _, cell := range spawnGuard(p) {
+						for _, m := range g.MovingObstacles {
+							for _, route := range m.cells() {
+								if route == cell {
+									t.Fatalf("seed %d, %d players, level %d: patrol crosses player %d's spawn at %v", seed, players, g.Level, p.ID+1, cell)
+								}
+							}
+						}
+						for i := range g.Breakers {
+							if g.Breakers[i].covers(cell) {
+								t.Fatalf("seed %d, %d players, level %d: breaker wall on player %d's spawn at %v", seed, players, g.Level, p.ID+1, cell)
+							}
+						}
+						if g.CellAt(cell) == CellObstacle {
+							t.Fatalf("seed %d, %d players, level %d: obstacle on player %d's spawn at %v", seed, players, g.Level, p.ID+1, cell)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// parseBoard reads an ASCII board: '#' is an obstacle, 'S' the spawn and 'A'
+// an alert
+func parseBoard(rows []string) (obstacles []Position, spawn Position, alerts []Position) {
+	for y, row := range rows {
+		for x, c := range row {
+			switch c {
+			case '#':
+				obstacles = append(obstacles, Position{X: x, Y: y})
+			case 'S':
+				spawn = Position{X: x, Y: y}
+			case 'A':
+				alerts = append(alerts, Position{X: x, Y: y})
+			}
+		}
+	}
+	return obstacles, spawn, alerts
+}
+
+func TestValidateLayout(t *testing.T) {
+	tests := []struct {
+		name        string
+		edges       EdgePolicy
+		board       []string
+		unreachable []int // Sizes of the unreachable regions
+		deadEnd     bool
+		enclosed    []Position
+	}{
+		{
+			name:  "open",
+			board: []string{"......", "......", "..S...", "......", "......", "......"},
+		},
+		{
+			name:        "enclosed pocket",
+			board:       []string{"..#...", ".#.#..", "..#...", "...S..", "......", "......"},
+			unreachable: []int{1},
+		},
+		{
+			name:        "boxed-in spawn",
+			board:       []string{"......", "...#..", "..#S#.", "...#..", "......", "......"},
+			unreachable: []int{31},
+			deadEnd:     true,
+		},
+		{
+			name:    "wall ahead of spawn",
+			board:   []string{"......", "......", "..S#..", "......", "......", "......"},
+			deadEnd: true,
+		},
+		{
+			name:        "alert in walled region",
+			board:       []string{"..A.#.", "....#.", "..S.#A", "....#.", "....#.", "....#."},
+			unreachable: []int{6},
+			enclosed:    []Position{{X: 5, Y: 2}},
+		},
+		{
+			name:  "wrapped edges reach past the wall",
+			edges: EdgeWrap,
+			board: []string{"..A.#.", "....#.", "..S.#A", "....#.", "....#.", "....#."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obstacles, spawn, alerts := parseBoard(tt.board)
+			report := ValidateLayout(len(tt.board[0]), len(tt.board), tt.edges, obstacles, spawn, Right, alerts)
+
+			var sizes []int
+			for _, region := range report.Unreachable {
+				sizes = append(sizes, len(region))
+			}
+			if !slices.Equal(sizes, tt.unreachable) {
+				t.Errorf("unreachable regions of %v cells, want %v", sizes, tt.unreachable)
+			}
+			if report.DeadEndSpawn != tt.deadEnd {
+				t.Errorf("dead-end spawn = %v, want %v", report.DeadEndSpawn, tt.deadEnd)
+			}
+			if !slices.Equal(report.EnclosedAlerts, tt.enclosed) {
+				t.Errorf("enclosed alerts %v, want %v", report.EnclosedAlerts, tt.enclosed)
+			}
+			if want := len(tt.unreachable) == 0 && !tt.deadEnd && len(tt.enclosed) == 0; report.OK() != want {
+				t.Errorf("OK() = %v, want %v: %s", report.OK(), want, report)
+			}
+		})
+	}
+}
+
+func TestBadLayoutsRebuilt(t *testing.T) {
+	pack := &LevelPack{Name: "Crowded", Levels: []Level{{
+		Name:           "Server Room",
+		Width:          20,
+		Height:         20,
+		TickIntervalMS: 100,
+		AlertsNeeded:   5,
+		AlertsOnScreen: 3,
+		Layout:         Layout{Generators: []Generator{{Type: "random", Params: map[string]int{"count": 90}}}},
+	}}}
+
+	bad := 0
+	for seed := int64(1); seed <= 50; seed++ {
+		g := New(20, 20, WithSeed(seed), WithClock(NewManualClock(time.Unix(0, 0))), WithLevelPack(pack))
+		g.Alerts = make([]Alert, 0)
+		state := g.src.state
+
+		// Lay out the board once without checking it
+		g.Obstacles = make([]Position, 0)
+		g.resetGrid()
+		g.applyLayout()
+		g.clearSpawn()
+		g.rebuildGrid()
+		if !g.ValidateBoard().OK() {
+			bad++
+		}
+
+		// The same draw through buildLayout must end up playable
+		g.src.state = state
+		g.buildLayout()
+		if report := g.ValidateBoard(); !report.OK() {
+			t.Fatalf("seed %d: built layout is unplayable: %s", seed, report)
+		}
+	}
+	if bad == 0 {
+		t.Fatal("no seed generated a bad board, so nothing was rebuilt")
+	}
+}
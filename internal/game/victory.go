@@ -0,0 +1,74 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// Endless mode scaling applied per level played past the end of the pack
+const (
+	endlessAlertsStep   = 2  // Extra alerts needed per endless level
+	endlessTickStepMS   = 2  // Milliseconds taken off the tick interval per endless level
+	endlessMinTickMS    = 30 // Fastest tick interval endless mode reaches
+	endlessDensityStep  = 2  // Extra random obstacles per endless level
+	endlessMaxObstacles = 30 // Cap on the extra random obstacles
+)
+
+// LevelResult records how a completed level went
+type LevelResult struct {
+	Level   int           `json:"level"`
+	Name    string        `json:"name"`
+	Score   int           `json:"score"`   // Points earned on the level, bonuses included
+	Elapsed time.Duration `json:"elapsed"` // Time taken to clear the level
+}
+
+// Summary is the final tally shown once every level has been cleared
+type Summary struct {
+	Score     int
+	TotalTime time.Duration
+	Levels    []LevelResult
+}
+
+// recordLevelResult appends the result of the level that was just completed
+func (g *Game) recordLevelResult() {
+	score := g.Score
+	for _, r := range g.Results {
+		score -= r.Score
+	}
+	g.Results = append(g.Results, LevelResult{
+		Level:   g.Level,
+		Name:    g.levelDef().Name,
+		Score:   score,
+		Elapsed: g.since(g.StartTime),
+	})
+}
+
+// GetSummary returns the final score, total time and per-level results of the
+// levels completed so far
+func (g *Game) GetSummary() Summary {
+	s := Summary{Score: g.Score, Levels: append([]LevelResult(nil), g.Results...)}
+	for _, r := range g.Results {
+		s.TotalTime += r.Elapsed
+	}
+	return s
+}
+
+// IsEndless reports whether the game keeps going past the last level
+func (g *Game) IsEndless() bool { return g.cfg.endless }
+
+// endlessLevel builds the definition for a level past the end of the pack by
+// scaling up the pack's last level
+func (p *LevelPack) endlessLevel(level int) Level {
+	last := p.Levels[len(p.Levels)-1]
+	n := level - len(p.Levels)
+
+	l := last
+	l.Name = fmt.Sprintf("Endless %d", n)
+	l.AlertsNeeded = last.AlertsNeeded + endlessAlertsStep*n
+	l.TickIntervalMS = max(min(endlessMinTickMS, last.TickIntervalMS), last.TickIntervalMS-endlessTickStepMS*n)
+	l.Layout.Generators = append(append([]Generator(nil), last.Layout.Generators...), Generator{
+		Type:   "random",
+		Params: map[string]int{"count": min(endlessMaxObstacles, endlessDensityStep*n)},
+	})
+	return l
+}
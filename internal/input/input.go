@@ -12,15 +12,45 @@ type InputHandler struct {
 	touchStartCallback js.Func
 	touchEndCallback js.Func
 	touchStartX, touchStartY float64
+	commands []game.Command // Commands received since the last call to Commands
+	players int // Commanders on the board; with two or more WASD steers player 2
+	pads map[int]int // Direction each gamepad last held, -1 for none
 }
 
 // New creates a new input handler
 func New() *InputHandler {
-	return &InputHandler{}
+	return &InputHandler{players: 1, pads: make(map[int]int)}
+}
+
+// SetPlayers tells the handler how many commanders share the keyboard and
+// gamepads. With one, arrows, WASD and every gamepad steer it; with more,
+// arrows steer player 1, WASD player 2 and gamepad n player n+1.
+func (h *InputHandler) SetPlayers(n int) {
+	h.players = n
+}
+
+// wasdPlayer returns the player steered with WASD
+func (h *InputHandler) wasdPlayer() int {
+	if h.players > 1 {
+		return 1
+	}
+	return 0
+}
+
+// Commands returns and clears the commands received since the last call
+func (h *InputHandler) Commands() []game.Command {
+	cmds := h.commands
+	h.commands = nil
+	return cmds
+}
+
+// push queues a command for the game loop
+func (h *InputHandler) push(cmd game.Command) {
+	h.commands = append(h.commands, cmd)
 }
 
 // SetupEventListeners sets up keyboard and touch event listeners
-func (h *InputHandler) SetupEventListeners(g *game.Game) {
+func (h *InputHandler) SetupEventListeners() {
 	document := js.Global().Get("document")
 
 	// Keyboard events
@@ -28,25 +58,44 @@ func (h *InputHandler) SetupEventListeners(g *game.Game) {
 		event := args[0]
 		key := event.Get("key").String()
 		
+		// Leave keys alone while typing into a form field, such as the
+		// online lobby's name and room code
+		switch event.Get("target").Get("tagName").String() {
+		case "INPUT", "SELECT", "TEXTAREA":
+			return nil
+		}
+		
 		switch key {
-		case "ArrowUp", "w", "W":
+		case "ArrowUp":
+			event.Call("preventDefault")
+			h.push(game.DirectionCommand(game.Direction(0))) // Up
+		case "ArrowDown":
 			event.Call("preventDefault")
-			g.SetDirection(game.Direction(0)) // Up
-		case "ArrowDown", "s", "S":
+			h.push(game.DirectionCommand(game.Direction(1))) // Down
+		case "ArrowLeft":
 			event.Call("preventDefault")
-			g.SetDirection(game.Direction(1)) // Down
-		case "ArrowLeft", "a", "A":
+			h.push(game.DirectionCommand(game.Direction(2))) // Left
+		case "ArrowRight":
 			event.Call("preventDefault")
-			g.SetDirection(game.Direction(2)) // Left
-		case "ArrowRight", "d", "D":
+			h.push(game.DirectionCommand(game.Direction(3))) // Right
+		case "w", "W":
 			event.Call("preventDefault")
-			g.SetDirection(game.Direction(3)) // Right
+			h.push(game.PlayerDirectionCommand(h.wasdPlayer(), game.Direction(0))) // Up
+		case "s", "S":
+			event.Call("preventDefault")
+			h.push(game.PlayerDirectionCommand(h.wasdPlayer(), game.Direction(1))) // Down
+		case "a", "A":
+			event.Call("preventDefault")
+			h.push(game.PlayerDirectionCommand(h.wasdPlayer(), game.Direction(2))) // Left
+		case "d", "D":
+			event.Call("preventDefault")
+			h.push(game.PlayerDirectionCommand(h.wasdPlayer(), game.Direction(3))) // Right
 		case " ", "p", "P":
 			event.Call("preventDefault")
-			g.Pause()
+			h.push(game.PauseCommand())
 		case "r", "R":
 			event.Call("preventDefault")
-			g.Restart()
+			h.push(game.RestartCommand())
 		}
 		
 		return nil
@@ -55,11 +104,11 @@ func (h *InputHandler) SetupEventListeners(g *game.Game) {
 	document.Call("addEventListener", "keydown", h.keyCallback)
 
 	// Touch events for mobile
-	h.setupTouchEvents(g)
+	h.setupTouchEvents()
 }
 
 // setupTouchEvents sets up touch events for mobile controls
-func (h *InputHandler) setupTouchEvents(g *game.Game) {
+func (h *InputHandler) setupTouchEvents() {
 	canvas := js.Global().Get("document").Call("getElementById", "game-canvas")
 	
 	// Touch start
@@ -97,22 +146,22 @@ func (h *InputHandler) setupTouchEvents(g *game.Game) {
 				// Horizontal swipe
 				if abs(deltaX) > minDistance {
 					if deltaX > 0 {
-						g.SetDirection(game.Direction(3)) // Right
+						h.push(game.DirectionCommand(game.Direction(3))) // Right
 					} else {
-						g.SetDirection(game.Direction(2)) // Left
+						h.push(game.DirectionCommand(game.Direction(2))) // Left
 					}
 				}
 			} else {
 				// Vertical swipe
 				if abs(deltaY) > minDistance {
 					if deltaY > 0 {
-						g.SetDirection(game.Direction(1)) // Down
+						h.push(game.DirectionCommand(game.Direction(1))) // Down
 					} else {
-						g.SetDirection(game.Direction(0)) // Up
+						h.push(game.DirectionCommand(game.Direction(0))) // Up
 					}
 				} else if abs(deltaX) < 10 && abs(deltaY) < 10 {
 					// This was a tap, pause the game
-					g.Pause()
+					h.push(game.PauseCommand())
 				}
 			}
 		}
@@ -124,11 +173,11 @@ func (h *InputHandler) setupTouchEvents(g *game.Game) {
 	canvas.Call("addEventListener", "touchend", h.touchEndCallback)
 	
 	// Set up on-screen buttons if they exist
-	h.setupOnScreenButtons(g)
+	h.setupOnScreenButtons()
 }
 
 // setupOnScreenButtons sets up on-screen button controls
-func (h *InputHandler) setupOnScreenButtons(g *game.Game) {
+func (h *InputHandler) setupOnScreenButtons() {
 	document := js.Global().Get("document")
 	
 	// Direction buttons
@@ -148,7 +197,7 @@ func (h *InputHandler) setupOnScreenButtons(g *game.Game) {
 			direction := btn.direction
 			callback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 				args[0].Call("preventDefault")
-				g.SetDirection(game.Direction(direction))
+				h.push(game.DirectionCommand(game.Direction(direction)))
 				return nil
 			})
 			element.Call("addEventListener", "touchstart", callback)
@@ -160,7 +209,7 @@ func (h *InputHandler) setupOnScreenButtons(g *game.Game) {
 	if !pauseBtn.IsNull() {
 		pauseCallback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 			args[0].Call("preventDefault")
-			g.Pause()
+			h.push(game.PauseCommand())
 			return nil
 		})
 		pauseBtn.Call("addEventListener", "touchstart", pauseCallback)
@@ -171,13 +220,76 @@ func (h *InputHandler) setupOnScreenButtons(g *game.Game) {
 	if !restartBtn.IsNull() {
 		restartCallback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 			args[0].Call("preventDefault")
-			g.Restart()
+			h.push(game.RestartCommand())
 			return nil
 		})
 		restartBtn.Call("addEventListener", "touchstart", restartCallback)
 	}
 }
 
+// PollGamepads reads the connected gamepads and queues a turn whenever a
+// pad's d-pad or left stick starts pointing a new way. Browsers only expose
+// gamepad state by polling, so call it once per animation frame.
+func (h *InputHandler) PollGamepads() {
+	navigator := js.Global().Get("navigator")
+	if navigator.Get("getGamepads").IsUndefined() {
+		return
+	}
+	pads := navigator.Call("getGamepads")
+	for i := 0; i < pads.Length(); i++ {
+		pad := pads.Index(i)
+		if pad.IsNull() || pad.IsUndefined() {
+			continue
+		}
+		player := 0
+		if h.players > 1 {
+			if i >= h.players {
+				continue
+			}
+			player = i
+		}
+
+		direction := gamepadDirection(pad)
+		if last, ok := h.pads[i]; ok && last == direction {
+			continue
+		}
+		h.pads[i] = direction
+		if direction >= 0 {
+			h.push(game.PlayerDirectionCommand(player, game.Direction(direction)))
+		}
+	}
+}
+
+// gamepadDirection returns the direction a standard-mapping gamepad's d-pad
+// or left stick points, or -1 when it is centred
+func gamepadDirection(pad js.Value) int {
+	buttons := pad.Get("buttons")
+	// D-pad buttons 12-15 of the standard mapping are up, down, left, right
+	for direction := 0; direction < 4; direction++ {
+		if button := 12 + direction; button < buttons.Length() && buttons.Index(button).Get("pressed").Bool() {
+			return direction
+		}
+	}
+
+	axes := pad.Get("axes")
+	if axes.Length() < 2 {
+		return -1
+	}
+	x, y := axes.Index(0).Float(), axes.Index(1).Float()
+	const deadZone = 0.5
+	switch {
+	case abs(x) < deadZone && abs(y) < deadZone:
+		return -1
+	case abs(x) > abs(y) && x > 0:
+		return 3 // Right
+	case abs(x) > abs(y):
+		return 2 // Left
+	case y > 0:
+		return 1 // Down
+	}
+	return 0 // Up
+}
+
 // Cleanup releases event listeners
 func (h *InputHandler) Cleanup() {
 	if !h.keyCallback.IsUndefined() {
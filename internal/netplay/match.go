@@ -0,0 +1,449 @@
+package netplay
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// Room timing and limits
+const (
+	restartDelay     = 5 * time.Second // Time the final board stays up before the room returns to the lobby
+	idleTimeout      = 2 * time.Minute // Time a room may sit without clients before it closes
+	sendBuffer       = 32              // Messages queued for a client before it is dropped as too slow
+	requestBuffer    = 64              // Client requests queued for the match goroutine
+	maxNameLength    = 16              // Longest player name kept, in runes
+	defaultBoardSize = 20              // Board size when a pack's first level does not set one
+)
+
+// RoomSettings are the match settings the host of a room controls
+type RoomSettings struct {
+	Pack       string `json:"pack"`        // Name of the level pack played
+	Mode       string `json:"mode"`        // Game mode name, as returned by game.GameMode.String
+	MaxPlayers int    `json:"max_players"` // Seats in the room, from 1 up to game.MaxPlayers
+}
+
+// Seat describes a seated player in a lobby message
+type Seat struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+}
+
+// Client is one connection to a room
+type Client struct {
+	Name  string
+	send  chan []byte
+	seat  int  // Seat of the client, which is its player ID in a game, or -1 for spectators; owned by run
+	ready bool // Whether the seated client is ready to start; owned by run
+	acked int  // Seq of the client's last turn applied to the game; owned by run
+}
+
+// Send returns the encoded messages for the client. The channel is closed
+// once the room has dropped the client.
+func (c *Client) Send() <-chan []byte { return c.send }
+
+// request is a message from a client for the match goroutine
+type request struct {
+	client *Client
+	msg    Message
+}
+
+// Match hosts one room: a lobby where players take seats, ready up and the
+// host picks the settings, and the authoritative game they then play. The
+// game starts once every seated player is ready, steps at its own tick rate
+// and broadcasts its state after every tick; when it ends the room returns
+// to the lobby. The player in the first seat hosts the room.
+//
+// Everything is owned by the goroutine running run; other goroutines talk
+// to it through Join, Leave and Handle.
+type Match struct {
+	code   string
+	packs  map[string]*game.LevelPack // Level packs the host can pick from, shared and read-only
+	names  []string                   // Names of packs, sorted
+	joins  chan *Client
+	leaves chan *Client
+	inbox  chan request
+	done   chan struct{} // Closed when run returns
+
+	settings RoomSettings
+	clients  []*Client // Connected clients in the order they joined
+	seats    []*Client // Seated clients; nil marks a player who left a running game
+	g        *game.Game
+	over     bool             // Whether g has finished and the room is about to return to the lobby
+	wake     <-chan time.Time // Fires for the next tick or the return to the lobby
+	idle     <-chan time.Time // Fires when the room has been empty for idleTimeout
+}
+
+// newMatch creates the match for a room; call run to start hosting it
+func newMatch(code string, packs map[string]*game.LevelPack, names []string) *Match {
+	return &Match{
+		code:     code,
+		packs:    packs,
+		names:    names,
+		joins:    make(chan *Client),
+		leaves:   make(chan *Client),
+		inbox:    make(chan request, requestBuffer),
+		done:     make(chan struct{}),
+		settings: RoomSettings{Pack: game.DefaultLevelPack().Name, Mode: game.LightCycle.String(), MaxPlayers: game.MaxPlayers},
+	}
+}
+
+// Code returns the code players join the room with
+func (m *Match) Code() string { return m.code }
+
+// Join connects a client under the given name. In the lobby it takes a free
+// seat if there is one; otherwise it spectates until the next lobby.
+func (m *Match) Join(name string) *Client {
+	c := &Client{Name: playerName(name), send: make(chan []byte, sendBuffer), seat: -1}
+	select {
+	case m.joins <- c:
+	case <-m.done:
+		close(c.send)
+	}
+	return c
+}
+
+// Leave disconnects a client and frees its seat. A commander left in a
+// running game keeps going the way it was heading.
+func (m *Match) Leave(c *Client) {
+	select {
+	case m.leaves <- c:
+	case <-m.done:
+	}
+}
+
+// Handle passes a message from the client to the room: a turn, a change of
+// ready state or, from the host, new settings
+func (m *Match) Handle(c *Client, msg Message) {
+	select {
+	case m.inbox <- request{client: c, msg: msg}:
+	case <-m.done:
+	}
+}
+
+// run hosts the room until ctx is cancelled or the room has been empty for
+// idleTimeout, then drops every client
+func (m *Match) run(ctx context.Context) {
+	defer close(m.done)
+	m.idle = time.After(idleTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			for len(m.clients) > 0 {
+				m.drop(m.clients[0])
+			}
+			return
+		case <-m.idle:
+			log.Printf("🧹 Room %s closed after sitting idle", m.code)
+			return
+		case c := <-m.joins:
+			m.join(c)
+		case c := <-m.leaves:
+			m.drop(c)
+			if m.g == nil {
+				m.seatSpectators()
+				m.broadcastLobby()
+			}
+		case r := <-m.inbox:
+			m.handle(r.client, r.msg)
+		case <-m.wake:
+			if m.over {
+				m.backToLobby()
+			} else {
+				m.tick()
+			}
+		}
+	}
+}
+
+// join seats a new client, or makes it a spectator, and brings it up to date
+func (m *Match) join(c *Client) {
+	m.clients = append(m.clients, c)
+	m.idle = nil
+	if m.g == nil && len(m.seats) < m.settings.MaxPlayers {
+		c.seat = len(m.seats)
+		m.seats = append(m.seats, c)
+	}
+	if c.Name == "" {
+		c.Name = fmt.Sprintf("Commander %d", len(m.clients))
+	}
+	log.Printf("🔌 %s joined room %s (seat %d)", c.Name, m.code, c.seat)
+	m.sendTo(c, Message{Type: MsgWelcome, Room: m.code, Player: c.seat})
+	if m.g != nil {
+		m.sendTo(c, m.startMessage(c))
+		m.sendTo(c, m.stateMessage())
+		return
+	}
+	m.broadcastLobby()
+}
+
+// drop disconnects a client. In the lobby its seat goes away and the
+// players after it move up; in a game its seat is kept empty so player IDs
+// stay put.
+func (m *Match) drop(c *Client) {
+	i := slices.Index(m.clients, c)
+	if i < 0 {
+		return
+	}
+	m.clients = slices.Delete(m.clients, i, i+1)
+	close(c.send)
+	if c.seat >= 0 {
+		if m.g == nil {
+			m.seats = slices.Delete(m.seats, c.seat, c.seat+1)
+			m.renumberSeats()
+		} else {
+			m.seats[c.seat] = nil
+		}
+	}
+	if len(m.clients) == 0 {
+		m.idle = time.After(idleTimeout)
+	}
+	log.Printf("🔌 %s left room %s", c.Name, m.code)
+}
+
+// handle acts on a request from a client
+func (m *Match) handle(c *Client, msg Message) {
+	if !slices.Contains(m.clients, c) {
+		return
+	}
+	switch msg.Type {
+	case MsgTurn:
+		if msg.Direction < game.Up || msg.Direction > game.Right {
+			m.refuse(c, "invalid direction")
+			return
+		}
+		if m.g != nil && !m.over && c.seat >= 0 {
+			m.g.Apply([]game.Command{game.PlayerDirectionCommand(c.seat, msg.Direction)})
+			c.acked = max(c.acked, msg.Seq)
+		}
+	case MsgReady:
+		if m.g != nil || c.seat < 0 {
+			return
+		}
+		c.ready = msg.Ready
+		if !m.start() {
+			m.broadcastLobby()
+		}
+	case MsgSettings:
+		if m.g != nil || c.seat != 0 {
+			m.refuse(c, "only the host can change settings, in the lobby")
+			return
+		}
+		if msg.Settings == nil {
+			m.refuse(c, "settings message has no settings")
+			return
+		}
+		if err := m.configure(*msg.Settings); err != nil {
+			m.refuse(c, err.Error())
+			return
+		}
+		m.broadcastLobby()
+	}
+}
+
+// configure applies new room settings. Players beyond a lowered seat limit
+// become spectators, spectators fill raised ones, and everybody has to
+// ready up again.
+func (m *Match) configure(s RoomSettings) error {
+	if _, ok := m.packs[s.Pack]; !ok {
+		return fmt.Errorf("unknown level pack %q", s.Pack)
+	}
+	if _, err := game.ParseGameMode(s.Mode); err != nil {
+		return fmt.Errorf("unknown game mode %q", s.Mode)
+	}
+	if s.MaxPlayers < 1 || s.MaxPlayers > game.MaxPlayers {
+		return fmt.Errorf("max players must be from 1 to %d", game.MaxPlayers)
+	}
+	m.settings = s
+	for _, c := range m.seats[min(len(m.seats), s.MaxPlayers):] {
+		c.seat = -1
+	}
+	m.seats = m.seats[:min(len(m.seats), s.MaxPlayers)]
+	m.seatSpectators()
+	for _, c := range m.seats {
+		c.ready = false
+	}
+	return nil
+}
+
+// start begins a game when enough players are seated and all of them are
+// ready, and reports whether it did. A room with a single seat can be
+// played solo; otherwise a game needs two players.
+func (m *Match) start() bool {
+	if len(m.seats) < min(2, m.settings.MaxPlayers) {
+		return false
+	}
+	for _, c := range m.seats {
+		if !c.ready {
+			return false
+		}
+	}
+	pack := m.packs[m.settings.Pack]
+	mode, _ := game.ParseGameMode(m.settings.Mode)
+	width, height := pack.Levels[0].Width, pack.Levels[0].Height
+	if width == 0 || height == 0 {
+		width, height = defaultBoardSize, defaultBoardSize
+	}
+	m.g = game.New(width, height,
+		game.WithLevelPack(pack),
+		game.WithMode(mode),
+		game.WithPlayers(len(m.seats)),
+		game.WithSeed(time.Now().UnixNano()))
+	m.over = false
+	for i, c := range m.seats {
+		m.g.GetPlayers()[i].Name = c.Name
+		c.acked = 0
+	}
+	log.Printf("🎮 Room %s started a %s game of %s with %d players (seed %d)", m.code, m.settings.Mode, m.settings.Pack, len(m.seats), m.g.GetSeed())
+	for _, c := range m.clients {
+		m.sendTo(c, m.startMessage(c))
+	}
+	m.broadcast(m.stateMessage())
+	m.wake = time.After(m.g.GetTickInterval())
+	return true
+}
+
+// tick steps the game, broadcasts the new state and schedules what comes
+// next
+func (m *Match) tick() {
+	m.g.Step(nil)
+	m.broadcast(m.stateMessage())
+	switch m.g.GetState() {
+	case game.GameOver, game.Victory, game.Saturated:
+		log.Printf("🏁 Room %s finished on level %d (winner %d)", m.code, m.g.GetLevel(), m.g.GetWinner())
+		m.over = true
+		m.wake = time.After(restartDelay)
+	default:
+		m.wake = time.After(m.g.GetTickInterval())
+	}
+}
+
+// backToLobby ends the finished game: players who left lose their seats,
+// spectators take free ones and everybody has to ready up again
+func (m *Match) backToLobby() {
+	m.g, m.over, m.wake = nil, false, nil
+	m.seats = slices.DeleteFunc(m.seats, func(c *Client) bool { return c == nil })
+	m.renumberSeats()
+	m.seatSpectators()
+	for _, c := range m.seats {
+		c.ready = false
+	}
+	m.broadcastLobby()
+}
+
+// seatSpectators gives free seats to spectators, longest waiting first
+func (m *Match) seatSpectators() {
+	for _, c := range m.clients {
+		if len(m.seats) >= m.settings.MaxPlayers {
+			return
+		}
+		if c.seat < 0 {
+			c.seat, c.ready = len(m.seats), false
+			m.seats = append(m.seats, c)
+		}
+	}
+}
+
+// renumberSeats brings the clients' seat numbers in line with the seats
+func (m *Match) renumberSeats() {
+	for i, c := range m.seats {
+		c.seat = i
+	}
+}
+
+// lobbyMessage returns the lobby as seen by client c
+func (m *Match) lobbyMessage(c *Client) Message {
+	seats := make([]Seat, len(m.seats))
+	for i, seated := range m.seats {
+		seats[i] = Seat{Name: seated.Name, Ready: seated.ready}
+	}
+	settings := m.settings
+	return Message{
+		Type:       MsgLobby,
+		Room:       m.code,
+		Player:     c.seat,
+		Seats:      seats,
+		Spectators: len(m.clients) - len(m.seats),
+		Settings:   &settings,
+		Packs:      m.names,
+	}
+}
+
+// startMessage returns the message announcing the game to client c
+func (m *Match) startMessage(c *Client) Message {
+	return Message{Type: MsgStart, Room: m.code, Player: c.seat, Pack: m.packs[m.settings.Pack]}
+}
+
+// stateMessage returns the message carrying the current game
+func (m *Match) stateMessage() Message {
+	state, err := game.Marshal(m.g)
+	if err != nil {
+		return Message{Type: MsgError, Player: -1, Error: err.Error()}
+	}
+	acks := make([]int, len(m.seats))
+	for i, c := range m.seats {
+		if c != nil {
+			acks[i] = c.acked
+		}
+	}
+	return Message{Type: MsgState, Player: -1, Tick: m.g.Tick, State: state, Acks: acks}
+}
+
+// broadcastLobby sends every client the lobby as it sees it
+func (m *Match) broadcastLobby() {
+	for _, c := range slices.Clone(m.clients) {
+		m.sendTo(c, m.lobbyMessage(c))
+	}
+}
+
+// broadcast sends the same message to every client
+func (m *Match) broadcast(msg Message) {
+	data, err := Encode(msg)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return
+	}
+	for _, c := range slices.Clone(m.clients) {
+		m.deliver(c, data)
+	}
+}
+
+// refuse tells a client its request was refused
+func (m *Match) refuse(c *Client, reason string) {
+	m.sendTo(c, Message{Type: MsgError, Player: c.seat, Error: reason})
+}
+
+// sendTo sends a message to one client
+func (m *Match) sendTo(c *Client, msg Message) {
+	data, err := Encode(msg)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return
+	}
+	m.deliver(c, data)
+}
+
+// deliver queues encoded data for a client, dropping clients that fall too
+// far behind rather than stalling the room
+func (m *Match) deliver(c *Client, data []byte) {
+	select {
+	case c.send <- data:
+	default:
+		log.Printf("🐢 %s is too slow, dropping", c.Name)
+		m.drop(c)
+	}
+}
+
+// playerName tidies a requested player name
+func playerName(name string) string {
+	name = strings.TrimSpace(name)
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+	return name
+}
@@ -0,0 +1,59 @@
+package netplay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// receive waits for the next message sent to the client
+func receive(t *testing.T, c *Client) Message {
+	t.Helper()
+	select {
+	case data := <-c.Send():
+		msg, err := Decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message from the room")
+	}
+	return Message{}
+}
+
+func TestTurnDirectionChecked(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rooms, err := NewRegistry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	room, err := rooms.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := room.Join("alice")
+	for _, want := range []string{MsgWelcome, MsgLobby} {
+		if msg := receive(t, c); msg.Type != want {
+			t.Fatalf("got a %s message, want %s", msg.Type, want)
+		}
+	}
+
+	for _, dir := range []game.Direction{-1, game.Right + 1, 1 << 20} {
+		room.Handle(c, Message{Type: MsgTurn, Direction: dir})
+		if msg := receive(t, c); msg.Type != MsgError {
+			t.Errorf("direction %d: got a %s message, want an error", dir, msg.Type)
+		}
+	}
+
+	// Valid turns in the lobby are ignored without complaint
+	room.Handle(c, Message{Type: MsgTurn, Direction: game.Left})
+	select {
+	case data := <-c.Send():
+		t.Errorf("valid turn got a reply: %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
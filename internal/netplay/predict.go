@@ -0,0 +1,150 @@
+package netplay
+
+import (
+	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// Prediction limits
+const (
+	maxLead   = 10 // Most ticks the prediction runs ahead of the latest server state
+	stepSlack = 2  // Ticks Step may add beyond the lead while a server state is late
+)
+
+// pendingTurn is a local turn the server has not acknowledged yet
+type pendingTurn struct {
+	seq  int
+	tick int // Predicted tick the turn was made on, before that tick's step
+	dir  game.Direction
+	sent time.Time
+}
+
+// Predictor runs the client's own copy of a room's game ahead of the
+// server so the local commander answers turns at once instead of a round
+// trip later. Every server state rewinds the prediction: the game is
+// restored from the state, and the turns the server has not acknowledged
+// yet are replayed on the ticks they were made on. Stepping is
+// deterministic, so the prediction only drifts from the server where other
+// players turned, and each state corrects that.
+//
+// Other commanders are predicted to keep going the way they head. The
+// prediction pauses whenever the server's game is not being played or the
+// local commander is out; Game then shows the server's state as it is.
+type Predictor struct {
+	seat  int // Player ID of the local commander, or -1 for spectators
+	pack  *game.LevelPack
+	clock *game.ManualClock // Never advanced: level timers come from the server states
+	wall  game.Clock        // Times round trips
+
+	g       *game.Game     // Predicted game, nil before the first state
+	server  int            // Tick of the latest server state
+	state   game.GameState // State of the latest server state
+	seq     int            // Sequence number of the latest local turn
+	pending []pendingTurn  // Unacknowledged turns, oldest first
+	rtt     time.Duration  // Smoothed time from sending a turn to the state acknowledging it
+}
+
+// NewPredictor creates the predictor for a game announced by a start
+// message, for the client in the given seat
+func NewPredictor(seat int, pack *game.LevelPack) *Predictor {
+	return &Predictor{seat: seat, pack: pack, clock: game.NewManualClock(time.Unix(0, 0)), wall: game.RealClock{}}
+}
+
+// Game returns the predicted game, or nil before the first server state
+func (p *Predictor) Game() *game.Game { return p.g }
+
+// ServerState returns the state of the server's game as of its latest state
+// message
+func (p *Predictor) ServerState() game.GameState { return p.state }
+
+// Turn turns the local commander in the prediction and returns the turn
+// message to send to the server
+func (p *Predictor) Turn(dir game.Direction) Message {
+	p.seq++
+	if p.predicting() {
+		p.pending = append(p.pending, pendingTurn{seq: p.seq, tick: p.g.Tick, dir: dir, sent: p.wall.Now()})
+		p.g.Apply([]game.Command{game.PlayerDirectionCommand(p.seat, dir)})
+	}
+	return Message{Type: MsgTurn, Direction: dir, Seq: p.seq}
+}
+
+// Step advances the prediction by one tick. Call it at the game's tick
+// interval to keep the prediction moving between server states; it holds
+// still once it is too far ahead of the latest one.
+func (p *Predictor) Step() {
+	if p.predicting() && p.g.Tick < p.server+p.lead()+stepSlack {
+		p.g.Step(nil)
+	}
+}
+
+// Reconcile rewinds the prediction to a server state and replays the turns
+// the server has not acknowledged yet, up to the lead the round trip calls
+// for
+func (p *Predictor) Reconcile(msg Message) error {
+	g, err := StateGame(msg, game.WithLevelPack(p.pack), game.WithClock(p.clock))
+	if err != nil {
+		return err
+	}
+	p.g, p.server, p.state = g, g.Tick, g.GetState()
+
+	acked := 0
+	if p.seat >= 0 && p.seat < len(msg.Acks) {
+		acked = msg.Acks[p.seat]
+	}
+	pending := p.pending[:0]
+	for _, turn := range p.pending {
+		switch {
+		case turn.seq > acked:
+			pending = append(pending, turn)
+		case turn.seq == acked:
+			p.sample(p.wall.Now().Sub(turn.sent))
+		}
+	}
+	p.pending = pending
+	if !p.predicting() {
+		p.pending = nil
+		return nil
+	}
+
+	// Turns the server has yet to see go in on the ticks they were made on,
+	// or straight away if the server is already past them
+	next := 0
+	for target := p.server + p.lead(); p.g.Tick < target && p.predicting(); {
+		for ; next < len(p.pending) && p.pending[next].tick <= p.g.Tick; next++ {
+			p.g.Apply([]game.Command{game.PlayerDirectionCommand(p.seat, p.pending[next].dir)})
+		}
+		p.g.Step(nil)
+	}
+	for ; next < len(p.pending); next++ {
+		p.g.Apply([]game.Command{game.PlayerDirectionCommand(p.seat, p.pending[next].dir)})
+	}
+	return nil
+}
+
+// predicting reports whether the prediction is running: the game is being
+// played and the local commander is still in it
+func (p *Predictor) predicting() bool {
+	return p.g != nil && p.seat >= 0 && p.seat < len(p.g.GetPlayers()) &&
+		p.g.GetState() == game.Playing && !p.g.GetPlayers()[p.seat].Out
+}
+
+// lead returns how many ticks the prediction runs ahead of the latest
+// server state: about a round trip, since the state is already half a round
+// trip old when it arrives and a turn takes the other half to reach the
+// server. The server applies a turn on the first tick after it arrives, so
+// with steady latency that is close to the tick it was predicted on; when
+// it is not, the next state corrects the prediction.
+func (p *Predictor) lead() int {
+	interval := p.g.GetTickInterval()
+	return max(1, min(int((p.rtt+interval/2)/interval), maxLead))
+}
+
+// sample folds a measured round trip into the smoothed one
+func (p *Predictor) sample(rtt time.Duration) {
+	if p.rtt == 0 {
+		p.rtt = rtt
+		return
+	}
+	p.rtt += (rtt - p.rtt) / 8
+}
@@ -0,0 +1,211 @@
+package netplay
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// latency is how many ticks turns and states spend in flight in the tests
+const latency = 3
+
+// inFlight is a message on its way, delivered on tick at
+type inFlight struct {
+	at  int
+	msg Message
+}
+
+// serverState builds the state message a room sends after a tick
+func serverState(t *testing.T, g *game.Game, acks []int) Message {
+	t.Helper()
+	state, err := game.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Message{Type: MsgState, Player: -1, Tick: g.Tick, State: state, Acks: append([]int(nil), acks...)}
+}
+
+// clearAhead reports whether the n cells ahead of the commander in
+// direction dir are free in g, on a board whose edges wrap
+func clearAhead(g *game.Game, seat int, dir game.Direction, n int) bool {
+	pos := g.GetPlayers()[seat].Commander
+	for i := 0; i < n; i++ {
+		switch dir {
+		case game.Up:
+			pos.Y--
+		case game.Down:
+			pos.Y++
+		case game.Left:
+			pos.X--
+		case game.Right:
+			pos.X++
+		}
+		pos.X = (pos.X + g.GetWidth()) % g.GetWidth()
+		pos.Y = (pos.Y + g.GetHeight()) % g.GetHeight()
+		if cell := g.CellAt(pos); cell == game.CellTrail || cell == game.CellObstacle {
+			return false
+		}
+	}
+	return true
+}
+
+// steer picks a random direction other than back the way the commander
+// came whose cells ahead are clear, or keeps the heading when none is
+func steer(g *game.Game, seat int, heading game.Direction, rng *rand.Rand) game.Direction {
+	reverse := map[game.Direction]game.Direction{game.Up: game.Down, game.Down: game.Up, game.Left: game.Right, game.Right: game.Left}
+	for _, i := range rng.Perm(4) {
+		if dir := game.Direction(i); dir != reverse[heading] && clearAhead(g, seat, dir, 10) {
+			return dir
+		}
+	}
+	return heading
+}
+
+func TestReconcileReplaysUnacknowledgedTurns(t *testing.T) {
+	pack := &game.LevelPack{Name: "Open", Levels: []game.Level{{
+		Name:           "Open Floor",
+		Width:          30,
+		Height:         30,
+		TickIntervalMS: 100,
+		AlertsNeeded:   1000,
+		AlertsOnScreen: 3,
+	}}}
+	clock := game.NewManualClock(time.Unix(0, 0))
+	server := game.New(30, 30, game.WithSeed(11), game.WithClock(clock), game.WithLevelPack(pack),
+		game.WithPlayers(2), game.WithWinCondition(game.HighScore), game.WithEdges(game.EdgeWrap))
+	p := NewPredictor(0, pack)
+	wall := game.NewManualClock(time.Unix(0, 0))
+	p.wall = wall
+	if err := p.Reconcile(serverState(t, server, []int{0, 0})); err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(5))
+	saved := map[int]string{server.Tick: string(serverState(t, server, nil).State)}
+	acks := []int{0, 0}
+	var turns, states []inFlight
+	var arrivals []int // Ticks turns reached the server on
+	heading := server.GetPlayers()[0].Direction
+
+	// prediction is a predicted game made with every turn acknowledged
+	type prediction struct {
+		made, tick int
+		state      string
+	}
+	var predictions []prediction
+	replayed := 0
+
+	for tick := 1; tick <= 150; tick++ {
+		wall.Advance(server.GetTickInterval())
+
+		// The client turns every few ticks, so some states find every turn
+		// acknowledged and others have turns still in flight
+		if tick <= 120 && (tick%16 == 0 || !clearAhead(p.Game(), 0, heading, 10)) {
+			heading = steer(p.Game(), 0, heading, rng)
+			turn := p.Turn(heading)
+			turns = append(turns, inFlight{at: tick + latency, msg: turn})
+			arrivals = append(arrivals, tick+latency)
+		}
+		p.Step()
+
+		// The server applies the turns that arrived, then steps
+		for len(turns) > 0 && turns[0].at <= tick {
+			turn := turns[0].msg
+			server.Apply([]game.Command{game.PlayerDirectionCommand(0, turn.Direction)})
+			acks[0] = max(acks[0], turn.Seq)
+			turns = turns[1:]
+		}
+		server.Step(nil)
+		state := serverState(t, server, acks)
+		saved[server.Tick] = string(state.State)
+		states = append(states, inFlight{at: tick + latency, msg: state})
+
+		for len(states) > 0 && states[0].at <= tick {
+			if err := p.Reconcile(states[0].msg); err != nil {
+				t.Fatal(err)
+			}
+			states = states[1:]
+			if lead := p.Game().Tick - p.server; p.predicting() && (lead < 1 || lead > maxLead) {
+				t.Fatalf("tick %d: prediction leads the server state by %d ticks", tick, lead)
+			}
+			if !p.predicting() {
+				continue
+			}
+
+			// Turns the server has yet to see are replayed on top of its state
+			if len(p.pending) > 0 {
+				player := p.Game().GetPlayers()[0]
+				last := player.Direction
+				if n := len(player.Turns); n > 0 {
+					last = player.Turns[n-1]
+				}
+				if last != heading {
+					t.Fatalf("tick %d: prediction heads %d after reconciling, want the latest turn %d", tick, last, heading)
+				}
+				replayed++
+				continue
+			}
+			got, err := game.Marshal(p.Game())
+			if err != nil {
+				t.Fatal(err)
+			}
+			predictions = append(predictions, prediction{made: tick, tick: p.Game().Tick, state: string(got)})
+		}
+	}
+	if len(p.pending) != 0 {
+		t.Fatalf("%d turns still unacknowledged", len(p.pending))
+	}
+
+	// With every turn acknowledged the prediction is the server's own game a
+	// few ticks on, unless a turn made since reached the server first
+	checked := 0
+	for _, pred := range predictions {
+		if slices.ContainsFunc(arrivals, func(at int) bool { return at > pred.made && at <= pred.tick }) {
+			continue
+		}
+		if want := saved[pred.tick]; pred.state != want {
+			t.Fatalf("prediction of tick %d made on tick %d differs from the server:\n got %s\nwant %s", pred.tick, pred.made, pred.state, want)
+		}
+		checked++
+	}
+	if checked < 40 || replayed < 20 {
+		t.Fatalf("only %d predictions were checked against the server and %d states replayed turns", checked, replayed)
+	}
+}
+
+func TestLeadStaysWithinMaxLead(t *testing.T) {
+	p := NewPredictor(0, game.DefaultLevelPack())
+	p.g = game.New(20, 20, game.WithSeed(1), game.WithClock(game.NewManualClock(time.Unix(0, 0))))
+	interval := p.g.GetTickInterval()
+
+	if got := p.lead(); got != 1 {
+		t.Errorf("lead before any round trip = %d, want 1", got)
+	}
+	for _, rtt := range []time.Duration{time.Millisecond, interval, 4 * interval, 19 * interval, time.Minute} {
+		p.rtt = 0
+		p.sample(rtt)
+		if got := p.lead(); got < 1 || got > maxLead {
+			t.Errorf("rtt %v: lead %d is outside 1..%d", rtt, got, maxLead)
+		}
+	}
+	p.rtt = 4 * interval
+	if got := p.lead(); got != 4 {
+		t.Errorf("rtt of 4 ticks: lead = %d, want 4", got)
+	}
+	p.rtt = time.Hour
+	if got := p.lead(); got != maxLead {
+		t.Errorf("rtt of an hour: lead = %d, want %d", got, maxLead)
+	}
+
+	// Step holds the prediction once it is far enough ahead
+	p.server = p.g.Tick
+	for i := 0; i < 3*maxLead; i++ {
+		p.Step()
+	}
+	if ahead := p.g.Tick - p.server; ahead > maxLead+stepSlack {
+		t.Errorf("Step ran %d ticks ahead of the server, want at most %d", ahead, maxLead+stepSlack)
+	}
+}
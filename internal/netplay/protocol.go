@@ -0,0 +1,77 @@
+// Package netplay hosts online rooms: players meet in a room's lobby, then
+// the server runs the authoritative game while clients send their turns and
+// render the state it broadcasts.
+package netplay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// Message types exchanged over a room connection
+const (
+	MsgHello    = "hello"    // Client to server: join Room under Name, or open a new room when Room is empty
+	MsgTurn     = "turn"     // Client to server: turn the client's commander to Direction, numbered Seq
+	MsgReady    = "ready"    // Client to server: set whether the client is Ready to start
+	MsgSettings = "settings" // Client to server: the host changes the room's Settings
+	MsgWelcome  = "welcome"  // Server to client: the Room joined and the seat given in Player
+	MsgLobby    = "lobby"    // Server to client: the room's Seats, Settings and level Packs
+	MsgStart    = "start"    // Server to client: a game started on Pack, with the client as Player
+	MsgState    = "state"    // Server to client: the game State after tick Tick and the turns it Acks
+	MsgError    = "error"    // Server to client: the request in Error was refused
+)
+
+// Message is a single message on a room connection. Only the fields used
+// by its type are set.
+type Message struct {
+	Type       string          `json:"type"`
+	Room       string          `json:"room,omitempty"` // Room code
+	Name       string          `json:"name,omitempty"`
+	Direction  game.Direction  `json:"direction,omitempty"` // Up is 0, so a missing direction is Up
+	Seq        int             `json:"seq,omitempty"`       // Number of a turn, counting up from 1 each game
+	Ready      bool            `json:"ready,omitempty"`
+	Player     int             `json:"player"`               // Seat of the client, which is its player ID in a game, or -1 for spectators
+	Seats      []Seat          `json:"seats,omitempty"`      // Seated players; the first one hosts the room
+	Spectators int             `json:"spectators,omitempty"` // Clients waiting for a seat
+	Settings   *RoomSettings   `json:"settings,omitempty"`
+	Packs      []string        `json:"packs,omitempty"` // Names of the level packs the host can pick
+	Pack       *game.LevelPack `json:"pack,omitempty"`  // Level pack of the game, needed to restore its states
+	Tick       int             `json:"tick,omitempty"`
+	State      json.RawMessage `json:"state,omitempty"` // The game as written by game.Marshal
+	Acks       []int           `json:"acks,omitempty"`  // Seq of the last turn from each seat that State includes
+	Error      string          `json:"error,omitempty"`
+}
+
+// Encode serializes a message for sending
+func Encode(m Message) ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("netplay: encoding %s message: %w", m.Type, err)
+	}
+	return data, nil
+}
+
+// Decode parses a received message
+func Decode(data []byte) (Message, error) {
+	var m Message
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Message{}, fmt.Errorf("netplay: invalid message: %w", err)
+	}
+	if m.Type == "" {
+		return Message{}, errors.New("netplay: message has no type")
+	}
+	return m, nil
+}
+
+// StateGame restores the game carried by a state message for rendering.
+// Pass game.WithLevelPack with the pack from the start message for games on
+// other packs than the built-in one.
+func StateGame(m Message, opts ...game.Option) (*game.Game, error) {
+	if m.Type != MsgState {
+		return nil, fmt.Errorf("netplay: %s message carries no state", m.Type)
+	}
+	return game.Unmarshal(m.State, opts...)
+}
@@ -0,0 +1,106 @@
+package netplay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// Room codes
+const (
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No 0/O or 1/I to mix up when reading codes aloud
+	codeLength   = 4
+	maxRooms     = 256 // Rooms open at once before creating more is refused
+)
+
+// ErrTooManyRooms is returned by Create when the registry is full
+var ErrTooManyRooms = errors.New("netplay: too many open rooms")
+
+// Registry keeps the open rooms in memory. Every room runs its match in its
+// own goroutine under a context derived from the registry's, and removes
+// itself once that goroutine ends: when the room sits idle or the
+// registry's context is cancelled.
+type Registry struct {
+	ctx   context.Context
+	packs map[string]*game.LevelPack
+	names []string
+
+	mu    sync.Mutex
+	rooms map[string]*Match
+}
+
+// NewRegistry creates a registry whose rooms can play the built-in level
+// pack and any extra packs given. Packs are told apart by name.
+func NewRegistry(ctx context.Context, packs ...*game.LevelPack) (*Registry, error) {
+	r := &Registry{
+		ctx:   ctx,
+		packs: make(map[string]*game.LevelPack),
+		rooms: make(map[string]*Match),
+	}
+	for _, pack := range append([]*game.LevelPack{game.DefaultLevelPack()}, packs...) {
+		if _, ok := r.packs[pack.Name]; ok {
+			return nil, fmt.Errorf("netplay: duplicate level pack %q", pack.Name)
+		}
+		r.packs[pack.Name] = pack
+		r.names = append(r.names, pack.Name)
+	}
+	slices.Sort(r.names)
+	return r, nil
+}
+
+// Create opens a new room with a fresh code and starts its match
+func (r *Registry) Create() (*Match, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.rooms) >= maxRooms {
+		return nil, ErrTooManyRooms
+	}
+	code := newCode()
+	for r.rooms[code] != nil {
+		code = newCode()
+	}
+	m := newMatch(code, r.packs, r.names)
+	r.rooms[code] = m
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	go func() {
+		defer cancel()
+		m.run(ctx)
+		r.mu.Lock()
+		delete(r.rooms, code)
+		r.mu.Unlock()
+	}()
+	log.Printf("🏠 Room %s opened", code)
+	return m, nil
+}
+
+// Find returns the open room with the given code, in any letter case
+func (r *Registry) Find(code string) (*Match, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.rooms[strings.ToUpper(strings.TrimSpace(code))]
+	return m, ok
+}
+
+// Len returns the number of open rooms
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.rooms)
+}
+
+// newCode returns a random room code
+func newCode() string {
+	code := make([]byte, codeLength)
+	for i := range code {
+		code[i] = codeAlphabet[rand.Intn(len(codeAlphabet))]
+	}
+	return string(code)
+}
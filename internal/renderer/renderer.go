@@ -1,8 +1,11 @@
 package renderer
 
 import (
+	"math"
 	"strconv"
+	"strings"
 	"syscall/js"
+	"time"
 
 	"github.com/nathannam/incident-commander-game/internal/game"
 )
@@ -11,7 +14,10 @@ import (
 type Renderer struct {
 	canvas js.Value
 	ctx    js.Value
-	cellSize int
+	cellSize float64 // Current cell size, eased toward targetCellSize
+	targetCellSize float64
+	pulsing bool // Whether the last frame drew a pulsing alert
+	summaryShown bool // Whether the victory panel lists the level results
 	mascotImg js.Value
 }
 
@@ -26,12 +32,14 @@ func New(canvas js.Value) *Renderer {
 	return &Renderer{
 		canvas: canvas,
 		ctx:    ctx,
-		cellSize: 30, // Will be calculated dynamically
+		cellSize: 0, // Will be calculated dynamically
 		mascotImg: mascotImg,
 	}
 }
 
-// updateCellSize calculates the cell size based on current canvas dimensions and grid size
+// updateCellSize calculates the cell size based on current canvas dimensions
+// and grid size, easing toward it so board size changes between levels zoom
+// smoothly instead of jumping
 func (r *Renderer) updateCellSize(g *game.Game) {
 	canvasWidth := r.canvas.Get("width").Int()
 	canvasHeight := r.canvas.Get("height").Int()
@@ -50,7 +58,20 @@ func (r *Renderer) updateCellSize(g *game.Game) {
 		gridSize = gridHeight
 	}
 	
-	r.cellSize = canvasSize / gridSize
+	r.targetCellSize = float64(canvasSize) / float64(gridSize)
+	
+	// Snap on the first frame and once close enough, ease otherwise
+	if r.cellSize == 0 || math.Abs(r.targetCellSize-r.cellSize) < 0.05 {
+		r.cellSize = r.targetCellSize
+	} else {
+		r.cellSize += (r.targetCellSize - r.cellSize) * 0.2
+	}
+}
+
+// Animating reports whether the board is still rescaling or alerts are
+// pulsing, so it should be rendered every frame
+func (r *Renderer) Animating() bool {
+	return r.cellSize != r.targetCellSize || r.pulsing
 }
 
 // Render renders the current game state
@@ -60,10 +81,11 @@ func (r *Renderer) Render(g *game.Game) {
 	
 	r.clearCanvas()
 	r.drawGrid(g)
+	r.drawEdges(g)
 	r.drawObstacles(g)
-	r.drawTrail(g)
+	r.drawTrails(g)
 	r.drawAlerts(g)
-	r.drawCommander(g)
+	r.drawCommanders(g)
 	r.drawUI(g)
 }
 
@@ -85,25 +107,64 @@ func (r *Renderer) drawGrid(g *game.Game) {
 	// Draw vertical lines
 	for x := 0; x <= width; x++ {
 		r.ctx.Call("beginPath")
-		r.ctx.Call("moveTo", x*r.cellSize, 0)
-		r.ctx.Call("lineTo", x*r.cellSize, height*r.cellSize)
+		r.ctx.Call("moveTo", float64(x)*r.cellSize, 0)
+		r.ctx.Call("lineTo", float64(x)*r.cellSize, float64(height)*r.cellSize)
 		r.ctx.Call("stroke")
 	}
 	
 	// Draw horizontal lines
 	for y := 0; y <= height; y++ {
 		r.ctx.Call("beginPath")
-		r.ctx.Call("moveTo", 0, y*r.cellSize)
-		r.ctx.Call("lineTo", width*r.cellSize, y*r.cellSize)
+		r.ctx.Call("moveTo", 0, float64(y)*r.cellSize)
+		r.ctx.Call("lineTo", float64(width)*r.cellSize, float64(y)*r.cellSize)
 		r.ctx.Call("stroke")
 	}
 }
 
-// drawCommander draws the incident commander using the mascot image
-func (r *Renderer) drawCommander(g *game.Game) {
-	commander := g.GetCommander()
-	x := commander.X * r.cellSize
-	y := commander.Y * r.cellSize
+// drawEdges marks board edges that do not end the game: dashed for
+// wrap-around edges and a thick rail for bouncing ones
+func (r *Renderer) drawEdges(g *game.Game) {
+	width := float64(g.GetWidth()) * r.cellSize
+	height := float64(g.GetHeight()) * r.cellSize
+	
+	switch g.GetEdges() {
+	case game.EdgeWrap:
+		r.ctx.Set("strokeStyle", "#9dd9f3")
+		r.ctx.Set("lineWidth", 2)
+		r.ctx.Call("setLineDash", []interface{}{r.cellSize / 2, r.cellSize / 2})
+		r.ctx.Call("strokeRect", 1, 1, width-2, height-2)
+		r.ctx.Call("setLineDash", []interface{}{})
+	case game.EdgeBounce:
+		r.ctx.Set("strokeStyle", "#ffd700")
+		r.ctx.Set("lineWidth", 4)
+		r.ctx.Call("strokeRect", 2, 2, width-4, height-4)
+	}
+}
+
+// drawCommanders draws every commander still in the match. With several
+// players each one gets a ring in its player color.
+func (r *Renderer) drawCommanders(g *game.Game) {
+	players := g.GetPlayers()
+	for _, p := range players {
+		if p.Out {
+			continue
+		}
+		r.drawCommander(p.Commander)
+		if len(players) > 1 {
+			r.ctx.Set("strokeStyle", p.Color)
+			r.ctx.Set("lineWidth", 3)
+			r.ctx.Call("beginPath")
+			r.ctx.Call("arc", (float64(p.Commander.X)+0.5)*r.cellSize, (float64(p.Commander.Y)+0.5)*r.cellSize, r.cellSize/2, 0, 2*math.Pi)
+			r.ctx.Call("stroke")
+			r.ctx.Set("lineWidth", 1)
+		}
+	}
+}
+
+// drawCommander draws an incident commander using the mascot image
+func (r *Renderer) drawCommander(commander game.Position) {
+	x := float64(commander.X) * r.cellSize
+	y := float64(commander.Y) * r.cellSize
 	
 	// Check if image is loaded and valid
 	imageLoaded := false
@@ -147,40 +208,99 @@ func (r *Renderer) drawCommander(g *game.Game) {
 	}
 }
 
-// drawTrail draws the commander's trail
-func (r *Renderer) drawTrail(g *game.Game) {
-	r.ctx.Set("fillStyle", "#6fcf3f")
+// drawTrails draws every commander's trail, in green for a lone commander
+// and in each player's color otherwise
+func (r *Renderer) drawTrails(g *game.Game) {
+	players := g.GetPlayers()
+	for _, p := range players {
+		color := "#6fcf3f"
+		if len(players) > 1 {
+			color = p.Color
+		}
+		r.drawTrail(g, p.Trail, color)
+	}
+}
+
+// drawTrail draws one trail: solid blocks in LightCycle mode and a snake
+// body tapering toward its tail in Classic mode
+func (r *Renderer) drawTrail(g *game.Game, trail []game.Position, color string) {
+	r.ctx.Set("fillStyle", color)
 	
-	trail := g.GetTrail()
+	if g.GetMode() == game.Classic {
+		for i, segment := range trail {
+			// Segments shrink and fade from the head back to the tail
+			t := float64(i+1) / float64(len(trail)+1)
+			inset := 2 + (1-t)*r.cellSize/4
+			r.ctx.Set("globalAlpha", 0.5+t/2)
+			x := float64(segment.X) * r.cellSize
+			y := float64(segment.Y) * r.cellSize
+			r.ctx.Call("fillRect", x+inset, y+inset, r.cellSize-2*inset, r.cellSize-2*inset)
+		}
+		r.ctx.Set("globalAlpha", 1)
+		return
+	}
 	for _, segment := range trail {
-		x := segment.X * r.cellSize
-		y := segment.Y * r.cellSize
+		x := float64(segment.X) * r.cellSize
+		y := float64(segment.Y) * r.cellSize
 		r.ctx.Call("fillRect", x+2, y+2, r.cellSize-4, r.cellSize-4)
 	}
 }
 
-// drawAlerts draws the alert bubbles
+// drawAlerts draws the alert bubbles, styled by severity so the most
+// critical alerts stand out
 func (r *Renderer) drawAlerts(g *game.Game) {
 	alerts := g.GetAlerts()
+	r.pulsing = false
 	
 	for _, alert := range alerts {
-		x := alert.X * r.cellSize
-		y := alert.Y * r.cellSize
+		x := float64(alert.X) * r.cellSize
+		y := float64(alert.Y) * r.cellSize
 		centerX := x + r.cellSize/2
 		centerY := y + r.cellSize/2
 		
-		// Draw red circle
-		r.ctx.Set("fillStyle", "#ff3838")
+		// SEV1 is a large red bubble, SEV2 orange and SEV3 a small yellow one
+		color, icon, inset := "#ffd700", "i", 5.0
+		switch alert.Severity {
+		case game.Sev1:
+			color, icon, inset = "#ff3838", "!!", 1
+		case game.Sev2:
+			color, icon, inset = "#ff6b35", "!", 3
+		}
+		radius := math.Max(2, r.cellSize/2-inset)
+		
+		// Escalated alerts pulse, faster as they get close to their deadline
+		if alert.Escalated || alert.TicksLeft <= 10 {
+			period := 600.0
+			if alert.TicksLeft <= 10 {
+				period = 250
+			}
+			r.pulsing = true
+			phase := math.Mod(js.Global().Get("performance").Call("now").Float(), period) / period
+			r.ctx.Set("strokeStyle", color)
+			r.ctx.Set("globalAlpha", 1-phase)
+			r.ctx.Set("lineWidth", 2)
+			r.ctx.Call("beginPath")
+			r.ctx.Call("arc", centerX, centerY, radius+phase*r.cellSize/2, 0, 2*3.14159)
+			r.ctx.Call("stroke")
+			r.ctx.Set("globalAlpha", 1)
+		}
+		
+		// Draw severity circle
+		r.ctx.Set("fillStyle", color)
 		r.ctx.Call("beginPath")
-		r.ctx.Call("arc", centerX, centerY, r.cellSize/2-3, 0, 2*3.14159)
+		r.ctx.Call("arc", centerX, centerY, radius, 0, 2*3.14159)
 		r.ctx.Call("fill")
 		
-		// Draw exclamation mark
-		r.ctx.Set("fillStyle", "#ffffff")
-		r.ctx.Set("font", strconv.Itoa(r.cellSize/2)+"px Arial")
+		// Draw severity icon
+		if alert.Severity == game.Sev3 {
+			r.ctx.Set("fillStyle", "#1a1f36")
+		} else {
+			r.ctx.Set("fillStyle", "#ffffff")
+		}
+		r.ctx.Set("font", "bold "+strconv.Itoa(int(radius))+"px Arial")
 		r.ctx.Set("textAlign", "center")
 		r.ctx.Set("textBaseline", "middle")
-		r.ctx.Call("fillText", "!", centerX, centerY)
+		r.ctx.Call("fillText", icon, centerX, centerY)
 	}
 }
 
@@ -190,10 +310,56 @@ func (r *Renderer) drawObstacles(g *game.Game) {
 	
 	obstacles := g.GetObstacles()
 	for _, obstacle := range obstacles {
-		x := obstacle.X * r.cellSize
-		y := obstacle.Y * r.cellSize
+		x := float64(obstacle.X) * r.cellSize
+		y := float64(obstacle.Y) * r.cellSize
 		r.ctx.Call("fillRect", x, y, r.cellSize, r.cellSize)
 	}
+	
+	// Circuit breaker walls: outlined while open, blinking while about to
+	// close and solid while closed
+	for _, breaker := range g.GetBreakers() {
+		for _, cell := range breaker.Cells {
+			x := float64(cell.X) * r.cellSize
+			y := float64(cell.Y) * r.cellSize
+			switch breaker.Phase {
+			case game.BreakerOpen:
+				r.ctx.Set("strokeStyle", "rgba(157, 217, 243, 0.35)")
+				r.ctx.Set("lineWidth", 1)
+				r.ctx.Call("strokeRect", x+2, y+2, r.cellSize-4, r.cellSize-4)
+			case game.BreakerWarning:
+				if g.Tick%2 == 0 {
+					r.ctx.Set("fillStyle", "rgba(255, 215, 0, 0.6)")
+				} else {
+					r.ctx.Set("fillStyle", "rgba(255, 56, 56, 0.35)")
+				}
+				r.ctx.Call("fillRect", x+2, y+2, r.cellSize-4, r.cellSize-4)
+			case game.BreakerClosed:
+				r.ctx.Set("fillStyle", "#7a2e3a")
+				r.ctx.Call("fillRect", x, y, r.cellSize, r.cellSize)
+				r.ctx.Set("strokeStyle", "#ff3838")
+				r.ctx.Set("lineWidth", 2)
+				r.ctx.Call("strokeRect", x+1, y+1, r.cellSize-2, r.cellSize-2)
+			}
+		}
+	}
+	
+	// Moving obstacles: faint dots along the patrol route, then the obstacle
+	for _, moving := range g.GetMovingObstacles() {
+		r.ctx.Set("fillStyle", "rgba(255, 159, 67, 0.25)")
+		for _, waypoint := range moving.Path {
+			r.ctx.Call("beginPath")
+			r.ctx.Call("arc", float64(waypoint.X)*r.cellSize+r.cellSize/2, float64(waypoint.Y)*r.cellSize+r.cellSize/2, r.cellSize/6, 0, 2*3.14159)
+			r.ctx.Call("fill")
+		}
+		
+		x := float64(moving.Position.X) * r.cellSize
+		y := float64(moving.Position.Y) * r.cellSize
+		r.ctx.Set("fillStyle", "#ff9f43")
+		r.ctx.Call("fillRect", x+1, y+1, r.cellSize-2, r.cellSize-2)
+		r.ctx.Set("strokeStyle", "#444444")
+		r.ctx.Set("lineWidth", 2)
+		r.ctx.Call("strokeRect", x+3, y+3, r.cellSize-6, r.cellSize-6)
+	}
 }
 
 // drawUI draws the user interface elements
@@ -201,16 +367,26 @@ func (r *Renderer) drawUI(g *game.Game) {
 	// Update DOM elements instead of drawing on canvas
 	document := js.Global().Get("document")
 	
-	// Update score
+	// Update score, one per player when several share the board
 	scoreEl := document.Call("getElementById", "score")
 	if !scoreEl.IsNull() {
 		scoreEl.Set("textContent", "Score: "+strconv.Itoa(g.GetScore()))
+		if players := g.GetPlayers(); len(players) > 1 {
+			scores := make([]string, len(players))
+			for i, p := range players {
+				scores[i] = p.Name + ": " + strconv.Itoa(p.Score)
+				if p.Out {
+					scores[i] += " 💀"
+				}
+			}
+			scoreEl.Set("textContent", strings.Join(scores, " | "))
+		}
 	}
 	
 	// Update level
 	levelEl := document.Call("getElementById", "level")
 	if !levelEl.IsNull() {
-		levelEl.Set("textContent", "Level: "+strconv.Itoa(g.GetLevel()))
+		levelEl.Set("textContent", "Level: "+strconv.Itoa(g.GetLevel())+" ("+g.CurrentLevel().Name+")")
 	}
 	
 	// Update alerts progress
@@ -220,26 +396,86 @@ func (r *Renderer) drawUI(g *game.Game) {
 		alertsEl.Set("textContent", alertsText)
 	}
 	
+	// Update combo meter: the streak and how much of its window is left
+	comboEl := document.Call("getElementById", "combo")
+	if !comboEl.IsNull() {
+		comboText := "Combo: -"
+		if combo := g.GetCombo(); combo > 0 {
+			filled := (g.GetComboTicksLeft()*5 + g.GetComboWindow() - 1) / g.GetComboWindow()
+			comboText = "Combo: x" + strconv.Itoa(combo) + " " + strings.Repeat("▮", filled) + strings.Repeat("▯", 5-filled)
+		}
+		comboEl.Set("textContent", comboText)
+	}
+	
 	// Update game state
 	stateEl := document.Call("getElementById", "game-state")
 	if !stateEl.IsNull() {
 		switch g.GetState() {
-		case 0: // Playing
+		case game.Playing:
 			stateEl.Set("textContent", "🎮 Playing")
+			if g.GetMode() == game.Classic {
+				stateEl.Set("textContent", "🎮 Playing (Classic)")
+			}
 			stateEl.Set("className", "playing")
-		case 1: // Paused
+		case game.Paused:
 			stateEl.Set("textContent", "⏸️ Paused")
 			stateEl.Set("className", "paused")
-		case 2: // GameOver
+		case game.GameOver:
 			stateEl.Set("textContent", "💀 Game Over")
+			if len(g.GetPlayers()) > 1 {
+				stateEl.Set("textContent", "🏁 Match Over: "+winnerText(g))
+			}
 			stateEl.Set("className", "game-over")
-		case 3: // LevelComplete
+		case game.LevelComplete:
 			message := "🎉 Level " + strconv.Itoa(g.GetLevel()) + " Complete!"
-			if g.GetLevel() < 10 {
+			if g.GetLevel() < g.GetLevelCount() || g.IsEndless() {
 				message += " → Level " + strconv.Itoa(g.GetLevel()+1)
 			}
 			stateEl.Set("textContent", message)
 			stateEl.Set("className", "level-complete")
+		case game.Victory:
+			summary := g.GetSummary()
+			stateEl.Set("textContent", "🏆 Victory! Final score "+strconv.Itoa(summary.Score)+" in "+summary.TotalTime.Round(time.Second).String())
+			if len(g.GetPlayers()) > 1 {
+				stateEl.Set("textContent", "🏆 All levels cleared! "+winnerText(g))
+			}
+			stateEl.Set("className", "victory")
+		case game.Saturated:
+			stateEl.Set("textContent", "🧱 Board Saturated: no room for new alerts")
+			stateEl.Set("className", "game-over")
 		}
 	}
-}
\ No newline at end of file
+	
+	r.drawSummary(document, g)
+}
+
+// drawSummary lists every cleared level with its score and time in the
+// victory panel, and hides the panel while the game is not won
+func (r *Renderer) drawSummary(document js.Value, g *game.Game) {
+	summaryEl := document.Call("getElementById", "victory-summary")
+	if summaryEl.IsNull() || r.summaryShown == (g.GetState() == game.Victory) {
+		return
+	}
+	r.summaryShown = !r.summaryShown
+	summaryEl.Set("textContent", "")
+	summaryEl.Get("style").Set("display", "none")
+	if !r.summaryShown {
+		return
+	}
+	
+	for _, level := range g.GetSummary().Levels {
+		item := document.Call("createElement", "li")
+		item.Set("textContent", "Level "+strconv.Itoa(level.Level)+" ("+level.Name+"): "+
+			strconv.Itoa(level.Score)+" pts in "+level.Elapsed.Round(time.Second).String())
+		summaryEl.Call("appendChild", item)
+	}
+	summaryEl.Get("style").Set("display", "block")
+}
+
+// winnerText names the winner of a finished match, or calls it a draw
+func winnerText(g *game.Game) string {
+	if winner := g.GetWinner(); winner >= 0 {
+		return g.GetPlayers()[winner].Name + " wins!"
+	}
+	return "Draw!"
+}
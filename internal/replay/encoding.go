@@ -0,0 +1,268 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// magic prefixes the binary replay encoding
+var magic = []byte("ICRP")
+
+// ErrUnsupportedVersion is returned when decoding a replay from a newer format
+var ErrUnsupportedVersion = errors.New("replay: unsupported version")
+
+// ErrObsoleteVersion is returned when decoding a replay older than
+// MinVersion, which would not play back the game it recorded
+var ErrObsoleteVersion = errors.New("replay: recorded by an older version of the game")
+
+// checkVersion reports whether a replay of the given version can be played
+func checkVersion(version int) error {
+	switch {
+	case version < 1 || version > Version:
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, version)
+	case version < MinVersion:
+		return fmt.Errorf("%w: version %d, this version plays %d and later", ErrObsoleteVersion, version, MinVersion)
+	}
+	return nil
+}
+
+// Commands are stored as one letter each in both encodings:
+// U, D, L, R turn the first player's commander, P toggles pause and X
+// restarts. Turns by other players are prefixed with the player's ID, so
+// "1L" turns player 2 left.
+const directionCodes = "UDLR"
+
+// encodeCommands converts commands to their letter codes
+func encodeCommands(cmds []game.Command) (string, error) {
+	codes := make([]byte, 0, len(cmds))
+	for _, cmd := range cmds {
+		switch cmd.Kind {
+		case game.CommandDirection:
+			if cmd.Direction < 0 || int(cmd.Direction) >= len(directionCodes) {
+				return "", fmt.Errorf("replay: invalid direction %d", cmd.Direction)
+			}
+			if cmd.Player < 0 || cmd.Player >= game.MaxPlayers {
+				return "", fmt.Errorf("replay: invalid player %d", cmd.Player)
+			}
+			if cmd.Player > 0 {
+				codes = append(codes, byte('0'+cmd.Player))
+			}
+			codes = append(codes, directionCodes[cmd.Direction])
+		case game.CommandPause:
+			codes = append(codes, 'P')
+		case game.CommandRestart:
+			codes = append(codes, 'X')
+		default:
+			return "", fmt.Errorf("replay: unknown command kind %d", cmd.Kind)
+		}
+	}
+	return string(codes), nil
+}
+
+// decodeCommands converts letter codes back to commands
+func decodeCommands(codes string) ([]game.Command, error) {
+	if codes == "" {
+		return nil, nil
+	}
+	cmds := make([]game.Command, 0, len(codes))
+	for i := 0; i < len(codes); i++ {
+		player := 0
+		if c := codes[i]; c >= '1' && c < '0'+game.MaxPlayers {
+			if i+1 == len(codes) || strings.IndexByte(directionCodes, codes[i+1]) < 0 {
+				return nil, fmt.Errorf("replay: player %q without a direction", c)
+			}
+			player = int(c - '0')
+			i++
+		}
+		switch c := codes[i]; c {
+		case 'U', 'D', 'L', 'R':
+			cmds = append(cmds, game.PlayerDirectionCommand(player, game.Direction(strings.IndexByte(directionCodes, c))))
+		case 'P':
+			cmds = append(cmds, game.PauseCommand())
+		case 'X':
+			cmds = append(cmds, game.RestartCommand())
+		default:
+			return nil, fmt.Errorf("replay: unknown command code %q", c)
+		}
+	}
+	return cmds, nil
+}
+
+// jsonReplay is the JSON layout of a replay. Each frame is written as
+// [elapsedMillis] or [elapsedMillis, "codes"] to keep files small.
+type jsonReplay struct {
+	Version int             `json:"version"`
+	Config  Config          `json:"config"`
+	Frames  [][]interface{} `json:"frames"`
+}
+
+// MarshalJSON encodes the replay as compact JSON
+func (r *Replay) MarshalJSON() ([]byte, error) {
+	out := jsonReplay{Version: Version, Config: r.Config, Frames: make([][]interface{}, len(r.Frames))}
+	for i, frame := range r.Frames {
+		codes, err := encodeCommands(frame.Commands)
+		if err != nil {
+			return nil, err
+		}
+		if codes == "" {
+			out.Frames[i] = []interface{}{frame.Elapsed.Milliseconds()}
+		} else {
+			out.Frames[i] = []interface{}{frame.Elapsed.Milliseconds(), codes}
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a replay written by MarshalJSON
+func (r *Replay) UnmarshalJSON(data []byte) error {
+	var in struct {
+		Version int               `json:"version"`
+		Config  Config            `json:"config"`
+		Frames  []json.RawMessage `json:"frames"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := checkVersion(in.Version); err != nil {
+		return err
+	}
+
+	frames := make([]Frame, len(in.Frames))
+	for i, raw := range in.Frames {
+		var fields []json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil || len(fields) < 1 || len(fields) > 2 {
+			return fmt.Errorf("replay: malformed frame %d", i)
+		}
+		var ms int64
+		if err := json.Unmarshal(fields[0], &ms); err != nil || ms < 0 {
+			return fmt.Errorf("replay: malformed elapsed time in frame %d", i)
+		}
+		frames[i].Elapsed = time.Duration(ms) * time.Millisecond
+		if len(fields) == 2 {
+			var codes string
+			if err := json.Unmarshal(fields[1], &codes); err != nil {
+				return fmt.Errorf("replay: malformed commands in frame %d", i)
+			}
+			cmds, err := decodeCommands(codes)
+			if err != nil {
+				return err
+			}
+			frames[i].Commands = cmds
+		}
+	}
+
+	*r = Replay{Version: in.Version, Config: in.Config, Frames: frames}
+	return nil
+}
+
+// MarshalBinary encodes the replay as magic, version and a length-prefixed
+// JSON config followed by one record per frame, with every number written
+// as a varint
+func (r *Replay) MarshalBinary() ([]byte, error) {
+	buf := bytes.NewBuffer(append([]byte{}, magic...))
+	tmp := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) { buf.Write(tmp[:binary.PutUvarint(tmp, v)]) }
+
+	cfg, err := json.Marshal(r.Config)
+	if err != nil {
+		return nil, err
+	}
+	putUvarint(Version)
+	putUvarint(uint64(len(cfg)))
+	buf.Write(cfg)
+	putUvarint(uint64(len(r.Frames)))
+
+	for _, frame := range r.Frames {
+		codes, err := encodeCommands(frame.Commands)
+		if err != nil {
+			return nil, err
+		}
+		putUvarint(uint64(frame.Elapsed.Milliseconds()))
+		putUvarint(uint64(len(codes)))
+		buf.WriteString(codes)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a replay written by MarshalBinary
+func (r *Replay) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, magic) {
+		return errors.New("replay: not a binary replay")
+	}
+	rd := bytes.NewReader(data[len(magic):])
+	fail := func(err error) error {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("replay: %w", err)
+	}
+
+	version, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return fail(err)
+	}
+	if err := checkVersion(int(version)); err != nil {
+		return err
+	}
+	var cfg Config
+	if err := readConfig(rd, &cfg); err != nil {
+		return fail(err)
+	}
+	count, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return fail(err)
+	}
+	if count > uint64(rd.Len()) {
+		return fail(io.ErrUnexpectedEOF)
+	}
+
+	frames := make([]Frame, count)
+	for i := range frames {
+		ms, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return fail(err)
+		}
+		codes, err := readBytes(rd)
+		if err != nil {
+			return fail(err)
+		}
+		frames[i].Elapsed = time.Duration(ms) * time.Millisecond
+		if frames[i].Commands, err = decodeCommands(string(codes)); err != nil {
+			return err
+		}
+	}
+
+	*r = Replay{Version: int(version), Config: cfg, Frames: frames}
+	return nil
+}
+
+// readBytes reads a uvarint length followed by that many bytes
+func readBytes(rd *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(rd.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(rd, data)
+	return data, err
+}
+
+// readConfig reads the length-prefixed JSON config
+func readConfig(rd *bytes.Reader, cfg *Config) error {
+	data, err := readBytes(rd)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, cfg)
+}
@@ -0,0 +1,198 @@
+package replay
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// Version is the current replay format version. Bump it together with
+// MinVersion whenever the same seed and inputs would play out differently.
+const Version = 6
+
+// MinVersion is the oldest replay format still played back. Older replays
+// were recorded against an engine that has since changed how games step, so
+// they would no longer reproduce the games they recorded.
+const MinVersion = 6
+
+// epoch is the clock start used for recorded and replayed games, so a replay
+// does not depend on when it was recorded
+var epoch = time.Unix(0, 0).UTC()
+
+// Config holds the game settings needed to rebuild a recorded session
+type Config struct {
+	Width  int             `json:"width"`
+	Height int             `json:"height"`
+	Seed   int64           `json:"seed"`
+	State  json.RawMessage `json:"state,omitempty"` // Saved game the session resumed from, if any
+
+	// Endless keeps the game going past the last level
+	Endless bool `json:"endless,omitempty"`
+
+	// Mode is the game mode played
+	Mode game.GameMode `json:"mode,omitempty"`
+
+	// Edges is the edge policy forced on every level, or empty to use the
+	// levels' own
+	Edges string `json:"edges,omitempty"`
+
+	// Players is the number of commanders on the board; 0 means one
+	Players int `json:"players,omitempty"`
+
+	// Win is how a match between several commanders is decided, or empty
+	// for last one standing
+	Win string `json:"win,omitempty"`
+
+	// Pack is the custom level pack played, or nil for the built-in levels
+	Pack *game.LevelPack `json:"level_pack,omitempty"`
+}
+
+// newGame builds the session's starting game running on clock
+func (c Config) newGame(clock game.Clock) (*game.Game, error) {
+	opts := []game.Option{game.WithClock(clock)}
+	if c.Pack != nil {
+		if err := c.Pack.Validate(); err != nil {
+			return nil, err
+		}
+		opts = append(opts, game.WithLevelPack(c.Pack))
+	}
+	if c.Endless {
+		opts = append(opts, game.WithEndless())
+	}
+	opts = append(opts, game.WithMode(c.Mode))
+	if c.Edges != "" {
+		edges, err := game.ParseEdgePolicy(c.Edges)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, game.WithEdges(edges))
+	}
+	if c.Players > 1 {
+		win, err := game.ParseWinCondition(c.Win)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, game.WithPlayers(c.Players), game.WithWinCondition(win))
+	}
+	if len(c.State) > 0 {
+		return game.Unmarshal(c.State, opts...)
+	}
+	return game.New(c.Width, c.Height, append(opts, game.WithSeed(c.Seed))...), nil
+}
+
+// Frame holds everything the game received during one tick: the commands
+// applied before the tick and the clock time that passed before it ran
+type Frame struct {
+	Elapsed  time.Duration  // Clock time since the previous tick, in whole milliseconds
+	Commands []game.Command // Commands applied before the tick
+}
+
+// Replay is a complete recorded session
+type Replay struct {
+	Version int
+	Config  Config
+	Frames  []Frame
+}
+
+// Recorder drives a game and records every input and tick it receives
+type Recorder struct {
+	game    *game.Game
+	clock   *game.ManualClock
+	source  game.Clock // Real clock the elapsed time is measured on
+	last    time.Time
+	pending []game.Command
+	replay  Replay
+}
+
+// NewRecorder creates a game from cfg and starts recording it. Elapsed time
+// is measured on source and rounded to milliseconds before the game sees it,
+// so playback observes exactly the same clock.
+func NewRecorder(cfg Config, source game.Clock) (*Recorder, error) {
+	clock := game.NewManualClock(epoch)
+	g, err := cfg.newGame(clock)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Width, cfg.Height, cfg.Seed = g.GetWidth(), g.GetHeight(), g.GetSeed()
+
+	return &Recorder{
+		game:   g,
+		clock:  clock,
+		source: source,
+		last:   source.Now(),
+		replay: Replay{Version: Version, Config: cfg},
+	}, nil
+}
+
+// Game returns the recorded game
+func (r *Recorder) Game() *game.Game { return r.game }
+
+// Replay returns the session recorded so far
+func (r *Recorder) Replay() *Replay { return &r.replay }
+
+// Apply applies commands immediately and records them for the next tick
+func (r *Recorder) Apply(cmds []game.Command) {
+	r.game.Apply(cmds)
+	r.pending = append(r.pending, cmds...)
+}
+
+// Step applies the commands, advances the game by one tick and records it
+func (r *Recorder) Step(cmds []game.Command) game.StepResult {
+	r.Apply(cmds)
+
+	now := r.source.Now()
+	elapsed := now.Sub(r.last).Round(time.Millisecond)
+	r.last = r.last.Add(elapsed)
+
+	r.replay.Frames = append(r.replay.Frames, Frame{Elapsed: elapsed, Commands: r.pending})
+	r.pending = nil
+
+	r.clock.Advance(elapsed)
+	return r.game.Step(nil)
+}
+
+// Player rebuilds a recorded session frame by frame
+type Player struct {
+	replay *Replay
+	game   *game.Game
+	clock  *game.ManualClock
+	frame  int
+}
+
+// NewPlayer creates the game described by the replay, ready for the first frame
+func NewPlayer(r *Replay) (*Player, error) {
+	clock := game.NewManualClock(epoch)
+	g, err := r.Config.newGame(clock)
+	if err != nil {
+		return nil, err
+	}
+	return &Player{replay: r, game: g, clock: clock}, nil
+}
+
+// Game returns the game being replayed
+func (p *Player) Game() *game.Game { return p.game }
+
+// Done reports whether every frame has been played
+func (p *Player) Done() bool { return p.frame >= len(p.replay.Frames) }
+
+// Next plays the next frame. It returns false once the replay is finished.
+func (p *Player) Next() (game.StepResult, bool) {
+	if p.Done() {
+		return game.StepResult{}, false
+	}
+	frame := p.replay.Frames[p.frame]
+	p.frame++
+
+	p.game.Apply(frame.Commands)
+	p.clock.Advance(frame.Elapsed)
+	return p.game.Step(nil), true
+}
+
+// PlayAll plays the remaining frames and returns the final game
+func (p *Player) PlayAll() *game.Game {
+	for !p.Done() {
+		p.Next()
+	}
+	return p.game
+}
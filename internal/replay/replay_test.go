@@ -0,0 +1,113 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nathannam/incident-commander-game/internal/game"
+)
+
+// record plays a short two-player session with turns by both players and a
+// pause, and returns the recorder
+func record(t *testing.T) *Recorder {
+	t.Helper()
+	source := game.NewManualClock(time.Unix(1000, 0))
+	rec, err := NewRecorder(Config{Width: 20, Height: 20, Seed: 7, Players: 2, Win: "score"}, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := map[int][]game.Command{
+		2:  {game.DirectionCommand(game.Left)},
+		3:  {game.PlayerDirectionCommand(1, game.Right)},
+		5:  {game.PauseCommand()},
+		8:  {game.PauseCommand(), game.PlayerDirectionCommand(1, game.Up)},
+		11: {game.DirectionCommand(game.Down), game.PlayerDirectionCommand(1, game.Left)},
+	}
+	for tick := 0; tick < 40; tick++ {
+		source.Advance(150*time.Millisecond + time.Duration(tick)*time.Millisecond)
+		rec.Step(script[tick])
+	}
+	return rec
+}
+
+// save serializes g for comparison
+func save(t *testing.T, g *game.Game) string {
+	t.Helper()
+	data, err := game.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// checkRoundTrip checks a decoded replay equals the recorded one and plays
+// back to the recorded game
+func checkRoundTrip(t *testing.T, rec *Recorder, decoded *Replay) {
+	t.Helper()
+	if !reflect.DeepEqual(decoded, rec.Replay()) {
+		t.Fatalf("decoded replay differs:\n got %+v\nwant %+v", decoded, rec.Replay())
+	}
+	player, err := NewPlayer(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := save(t, player.PlayAll()), save(t, rec.Game()); got != want {
+		t.Fatalf("playback differs from the recorded game:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	rec := record(t)
+	data, err := json.Marshal(rec.Replay())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Replay
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	checkRoundTrip(t, rec, &decoded)
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	rec := record(t)
+	data, err := rec.Replay().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Replay
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	checkRoundTrip(t, rec, &decoded)
+}
+
+func TestVersions(t *testing.T) {
+	binaryReplay := func(version uint64) []byte {
+		return binary.AppendUvarint(bytes.Clone(magic), version)
+	}
+	tests := []struct {
+		version int
+		want    error
+	}{
+		{0, ErrUnsupportedVersion},
+		{1, ErrObsoleteVersion},
+		{MinVersion - 1, ErrObsoleteVersion},
+		{Version + 1, ErrUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		var r Replay
+		data, _ := json.Marshal(jsonReplay{Version: tt.version})
+		if err := json.Unmarshal(data, &r); !errors.Is(err, tt.want) {
+			t.Errorf("JSON version %d: got %v, want %v", tt.version, err, tt.want)
+		}
+		if err := r.UnmarshalBinary(binaryReplay(uint64(tt.version))); !errors.Is(err, tt.want) {
+			t.Errorf("binary version %d: got %v, want %v", tt.version, err, tt.want)
+		}
+	}
+}